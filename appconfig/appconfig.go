@@ -0,0 +1,144 @@
+package appconfig
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Nilai bawaan untuk development lokal. Produksi wajib mengatur PUBLIC_BASE_URL dan LINK_SIGNING_KEY.
+const (
+	DefaultPublicBaseURL = "http://localhost:8080"
+	DefaultPortalLinkTTL = 7 * 24 * time.Hour
+	minSigningKeyLength  = 32
+)
+
+/*
+Config adalah konfigurasi aplikasi yang dipakai lintas package. Package ini sengaja tidak mengimpor package lain di
+dalam medis supaya helper, controllers dan config bisa memakainya tanpa import cycle.
+PublicBaseURL adalah alamat yang dilihat pengguna dan menjadi dasar semua link di email dan pesan.
+LinkSigningKey dipakai untuk menandatangani link yang bisa dibuka tanpa login, misalnya link portal pasien.
+*/
+type Config struct {
+	PublicBaseURL  string
+	LinkSigningKey []byte
+	PortalLinkTTL  time.Duration
+}
+
+var (
+	mu      sync.RWMutex
+	current *Config
+)
+
+/*
+LoadFromEnv membaca PUBLIC_BASE_URL, LINK_SIGNING_KEY dan PORTAL_LINK_TTL_HOURS. LINK_SIGNING_KEY wajib diisi jika
+PUBLIC_BASE_URL bukan localhost. Untuk development lokal kunci acak dibuat untuk proses ini saja, sehingga link
+bertanda tangan tidak berlaku lagi setelah aplikasi di-restart.
+*/
+func LoadFromEnv() (*Config, error) {
+	baseURL := os.Getenv("PUBLIC_BASE_URL")
+	if baseURL == "" {
+		baseURL = DefaultPublicBaseURL
+	}
+	baseURL, err := normalizeBaseURL(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	signingKey := []byte(os.Getenv("LINK_SIGNING_KEY"))
+	if len(signingKey) == 0 {
+		if !isLocalBaseURL(baseURL) {
+			return nil, errors.New("LINK_SIGNING_KEY is required when PUBLIC_BASE_URL is not localhost")
+		}
+		log.Println("LINK_SIGNING_KEY is not set, signed links will stop working after a restart")
+		signingKey = make([]byte, minSigningKeyLength)
+		if _, err := rand.Read(signingKey); err != nil {
+			return nil, err
+		}
+	} else if len(signingKey) < minSigningKeyLength {
+		return nil, fmt.Errorf("LINK_SIGNING_KEY must be at least %d bytes", minSigningKeyLength)
+	}
+
+	portalLinkTTL := DefaultPortalLinkTTL
+	if value := os.Getenv("PORTAL_LINK_TTL_HOURS"); value != "" {
+		hours, err := strconv.Atoi(value)
+		if err != nil || hours < 1 {
+			return nil, errors.New("PORTAL_LINK_TTL_HOURS must be a positive number of hours")
+		}
+		portalLinkTTL = time.Duration(hours) * time.Hour
+	}
+
+	return &Config{
+		PublicBaseURL:  baseURL,
+		LinkSigningKey: signingKey,
+		PortalLinkTTL:  portalLinkTTL,
+	}, nil
+}
+
+// normalizeBaseURL memastikan PUBLIC_BASE_URL berupa URL http(s) absolut tanpa query dan tanpa garis miring di akhir
+func normalizeBaseURL(value string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(value))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return "", fmt.Errorf("PUBLIC_BASE_URL must be an absolute http or https URL, got %q", value)
+	}
+	return strings.TrimRight(parsed.String(), "/"), nil
+}
+
+// isLocalBaseURL mengenali alamat development lokal, yaitu localhost dan alamat loopback
+func isLocalBaseURL(baseURL string) bool {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+	host := parsed.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Set mengganti konfigurasi yang dipakai aplikasi. Dipanggil sekali saat aplikasi dijalankan, atau dari pengujian.
+func Set(config *Config) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = config
+}
+
+// Get mengembalikan konfigurasi aktif. Jika Set belum dipanggil, konfigurasi dibaca dari environment.
+func Get() *Config {
+	mu.RLock()
+	config := current
+	mu.RUnlock()
+	if config != nil {
+		return config
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		loaded, err := LoadFromEnv()
+		if err != nil {
+			log.Fatal(err)
+		}
+		current = loaded
+	}
+	return current
+}
+
+// URL menyusun alamat publik dari path dan query, misalnya URL("/verify", url.Values{"token": {"abc"}})
+func (c *Config) URL(path string, query url.Values) string {
+	link := c.PublicBaseURL + "/" + strings.TrimLeft(path, "/")
+	if len(query) > 0 {
+		link += "?" + query.Encode()
+	}
+	return link
+}
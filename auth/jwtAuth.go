@@ -3,63 +3,126 @@ package auth
 import (
 	"errors"
 	"github.com/golang-jwt/jwt"
-	"log"
-	"os"
 	"time"
 )
 
+// Access token dibuat berumur pendek, sesi yang panjang dipegang oleh refresh token di database
+const (
+	AccessTokenDuration    = 15 * time.Minute
+	RefreshTokenDuration   = 30 * 24 * time.Hour
+	ChallengeTokenDuration = 5 * time.Minute
+	// Layar antrian di ruang tunggu menyala seharian, token-nya cukup berlaku satu hari kerja
+	QueueDisplayTokenDuration = 12 * time.Hour
+)
+
+// TokenIssuer dicantumkan pada klaim iss supaya service lain bisa memastikan token berasal dari medis
+const TokenIssuer = "medis"
+
+// Purpose membedakan token tantangan 2FA dan token layar antrian dari access token biasa (Purpose kosong)
+const (
+	PurposeMFAChallenge = "mfa_challenge"
+	PurposeQueueDisplay = "queue_display"
+)
+
 type Claims struct {
-	Username string `json:"username"`
+	Username  string `json:"username"`
+	Role      string `json:"role,omitempty"`
+	SessionID uint   `json:"sid"`
+	// Klinik aktif, semua query data pasien dibatasi ke organisasi ini
+	OrganizationID uint `json:"org_id,omitempty"`
+	// Hanya diisi pada token layar antrian, yaitu dokter yang antriannya ditampilkan
+	DoctorID uint   `json:"doctor_id,omitempty"`
+	Purpose  string `json:"purpose,omitempty"`
 	jwt.StandardClaims
 }
 
-func GenerateToken(username string, secretKey []byte) (string, error) {
+// GenerateToken menandatangani access token dari klaim identitas (username, role, sesi);
+// waktu terbit dan kedaluwarsa diisi di sini
+func GenerateToken(claims Claims, keys *KeySet) (string, error) {
 	// Durasi token berlaku
-	expirationTime := time.Now().Add(24 * time.Hour)
+	expirationTime := time.Now().Add(AccessTokenDuration)
 
 	// Membuat klaim JWT
+	claims.Purpose = ""
+	claims.StandardClaims = jwt.StandardClaims{
+		Issuer:    TokenIssuer,
+		Subject:   claims.Username,
+		ExpiresAt: expirationTime.Unix(),
+		IssuedAt:  time.Now().Unix(),
+	}
+
+	// Menandatangani token dengan kunci aktif (RS256 atau EdDSA)
+	return keys.sign(&claims)
+}
+
+// GenerateChallengeToken membuat token berumur pendek yang hanya membuktikan bahwa langkah password sudah lolos
+func GenerateChallengeToken(username string, keys *KeySet) (string, error) {
 	claims := &Claims{
 		Username: username,
+		Purpose:  PurposeMFAChallenge,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
+			Issuer:    TokenIssuer,
+			Subject:   username,
+			ExpiresAt: time.Now().Add(ChallengeTokenDuration).Unix(),
 			IssuedAt:  time.Now().Unix(),
 		},
 	}
 
-	// Membuat token JWT dengan metode HMAC
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return keys.sign(claims)
+}
 
-	// Menandatangani token dengan kunci rahasia
-	tokenString, err := token.SignedString(secretKey)
+func VerifyChallengeToken(tokenString string, keys *KeySet) (*Claims, error) {
+	claims, err := VerifyToken(tokenString, keys)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if claims.Purpose != PurposeMFAChallenge {
+		return nil, errors.New("Invalid challenge token")
+	}
+	return claims, nil
+}
+
+// GenerateQueueDisplayToken membuat token yang hanya bisa dipakai untuk membaca nomor antrian satu dokter di satu klinik
+func GenerateQueueDisplayToken(organizationID, doctorID uint, keys *KeySet) (string, time.Time, error) {
+	expiresAt := time.Now().Add(QueueDisplayTokenDuration)
+	claims := &Claims{
+		OrganizationID: organizationID,
+		DoctorID:       doctorID,
+		Purpose:        PurposeQueueDisplay,
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    TokenIssuer,
+			ExpiresAt: expiresAt.Unix(),
+			IssuedAt:  time.Now().Unix(),
+		},
 	}
 
-	return tokenString, nil
+	token, err := keys.sign(claims)
+	return token, expiresAt, err
 }
 
-func VerifyToken(tokenString string, secretKey []byte) (string, error) {
-	// Parsing token dengan secret key
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return secretKey, nil
-	})
+func VerifyQueueDisplayToken(tokenString string, keys *KeySet) (*Claims, error) {
+	claims, err := VerifyToken(tokenString, keys)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != PurposeQueueDisplay {
+		return nil, errors.New("Invalid queue display token")
+	}
+	return claims, nil
+}
+
+func VerifyToken(tokenString string, keys *KeySet) (*Claims, error) {
+	// Parsing token, kunci dipilih dari header kid dan algoritmanya harus cocok dengan kunci tersebut
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keys.keyFunc)
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// Memeriksa apakah token valid
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims.Username, nil
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid && claims.VerifyIssuer(TokenIssuer, true) {
+		return claims, nil
 	} else {
-		return "", errors.New("Invalid token")
-	}
-}
-
-func GetSecretKeyFromEnv() string {
-	secretKey := os.Getenv("SECRET_KEY")
-	if secretKey == "" {
-		log.Fatal("SECRET_KEY tidak ditemukan di .env")
+		return nil, errors.New("Invalid token")
 	}
-	return secretKey
 }
@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SigningKey adalah satu pasangan kunci JWT. PrivateKey kosong berarti kunci lama yang
+// hanya dipakai untuk memverifikasi token yang masih beredar setelah rotasi.
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.PrivateKey
+	PublicKey  crypto.PublicKey
+}
+
+// KeySet menyimpan satu kunci aktif untuk menandatangani dan semua kunci yang masih diterima saat verifikasi
+type KeySet struct {
+	signingKey *SigningKey
+	keys       map[string]*SigningKey
+}
+
+/*
+Function untuk memuat kunci JWT dari direktori JWT_KEYS_DIR (default "keys"). Setiap file <kid>.pem berisi
+private key RSA (PKCS#1/PKCS#8) atau Ed25519 (PKCS#8), atau public key saja untuk kunci yang sudah dipensiunkan.
+JWT_SIGNING_KEY_ID memilih kunci yang dipakai untuk menandatangani token baru.
+Contoh membuat kunci baru: openssl genpkey -algorithm ed25519 -out keys/2024-06.pem
+*/
+func LoadKeySetFromEnv() *KeySet {
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		dir = "keys"
+	}
+
+	keySet, err := LoadKeySet(dir, os.Getenv("JWT_SIGNING_KEY_ID"))
+	if err != nil {
+		log.Fatal("Gagal memuat kunci JWT: ", err)
+	}
+	return keySet
+}
+
+func LoadKeySet(dir, signingKeyID string) (*KeySet, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	keySet := &KeySet{keys: map[string]*SigningKey{}}
+	var privateKeyIDs []string
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := parseSigningKey(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		keySet.keys[kid] = key
+		if key.PrivateKey != nil {
+			privateKeyIDs = append(privateKeyIDs, kid)
+		}
+	}
+
+	if signingKeyID == "" {
+		if len(privateKeyIDs) != 1 {
+			return nil, errors.New("JWT_SIGNING_KEY_ID harus diisi jika jumlah private key bukan satu")
+		}
+		signingKeyID = privateKeyIDs[0]
+	}
+
+	signingKey, ok := keySet.keys[signingKeyID]
+	if !ok || signingKey.PrivateKey == nil {
+		return nil, fmt.Errorf("private key untuk kid %q tidak ditemukan di %s", signingKeyID, dir)
+	}
+	keySet.signingKey = signingKey
+
+	return keySet, nil
+}
+
+func parseSigningKey(kid string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("file bukan PEM")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("tipe PEM %q tidak didukung", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, errors.New("hanya kunci RSA dan Ed25519 yang didukung")
+	}
+
+	if rsaKey, ok := key.PublicKey.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < 2048 {
+		return nil, errors.New("kunci RSA minimal 2048 bit")
+	}
+	return key, nil
+}
+
+// sign menandatangani klaim dengan kunci aktif dan mencantumkan kid pada header token
+func (k *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signingKey.Method, claims)
+	token.Header["kid"] = k.signingKey.ID
+	return token.SignedString(k.signingKey.PrivateKey)
+}
+
+// keyFunc memilih public key berdasarkan kid dan menolak token yang algoritmanya tidak sesuai dengan kunci tersebut
+func (k *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, errors.New("Unknown signing key")
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("Unexpected signing method")
+	}
+	return key.PublicKey, nil
+}
+
+// JSONWebKey mengikuti format RFC 7517 untuk kunci RSA dan OKP (Ed25519)
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS mengembalikan semua public key yang masih diterima, untuk dipublikasikan ke service lain
+func (k *KeySet) JWKS() JSONWebKeySet {
+	ids := make([]string, 0, len(k.keys))
+	for kid := range k.keys {
+		ids = append(ids, kid)
+	}
+	sort.Strings(ids)
+
+	jwks := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, kid := range ids {
+		key := k.keys[kid]
+		jwk := JSONWebKey{Kid: kid, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
@@ -0,0 +1,105 @@
+package auth
+
+// Role yang bisa dimiliki oleh akun staf klinik
+const (
+	RoleAdmin        = "admin"
+	RoleDoctor       = "doctor"
+	RoleNurse        = "nurse"
+	RoleReceptionist = "receptionist"
+)
+
+type Permission string
+
+const (
+	PermissionRecordRead             Permission = "medical_record:read"
+	PermissionRecordManageAll        Permission = "medical_record:manage_all"
+	PermissionRecordCreate           Permission = "medical_record:create"
+	PermissionRecordEditClinical     Permission = "medical_record:edit_clinical"
+	PermissionRecordEditDemographics Permission = "medical_record:edit_demographics"
+	PermissionRecordDelete           Permission = "medical_record:delete"
+	PermissionPatientRead            Permission = "patient:read"
+	PermissionPatientWrite           Permission = "patient:write"
+	PermissionPatientDelete          Permission = "patient:delete"
+	PermissionPatientHistoryWrite    Permission = "patient_history:write"
+	PermissionVitalsWrite            Permission = "vitals:write"
+	PermissionScheduleManage         Permission = "schedule:manage"
+	PermissionAppointmentManage      Permission = "appointment:manage"
+	PermissionQueueManage            Permission = "queue:manage"
+	PermissionUserManage             Permission = "user:manage"
+	PermissionLicenseApprove         Permission = "license:approve"
+	PermissionOutboxManage           Permission = "outbox:manage"
+)
+
+// Matriks permission per role. Semua anggota klinik dengan medical_record:read bisa membaca rekam medis
+// kliniknya, tetapi tanpa medical_record:manage_all sebuah role hanya bisa mengubah rekam medis miliknya sendiri.
+var rolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermissionRecordRead,
+		PermissionRecordManageAll,
+		PermissionRecordCreate,
+		PermissionRecordEditClinical,
+		PermissionRecordEditDemographics,
+		PermissionRecordDelete,
+		PermissionPatientRead,
+		PermissionPatientWrite,
+		PermissionPatientDelete,
+		PermissionPatientHistoryWrite,
+		PermissionVitalsWrite,
+		PermissionScheduleManage,
+		PermissionAppointmentManage,
+		PermissionQueueManage,
+		PermissionUserManage,
+		PermissionLicenseApprove,
+		PermissionOutboxManage,
+	},
+	RoleDoctor: {
+		PermissionRecordRead,
+		PermissionRecordCreate,
+		PermissionRecordEditClinical,
+		PermissionRecordEditDemographics,
+		PermissionRecordDelete,
+		PermissionPatientRead,
+		PermissionPatientWrite,
+		PermissionPatientDelete,
+		PermissionPatientHistoryWrite,
+		PermissionVitalsWrite,
+		PermissionScheduleManage,
+		PermissionAppointmentManage,
+		PermissionQueueManage,
+	},
+	RoleNurse: {
+		PermissionRecordRead,
+		PermissionRecordManageAll,
+		PermissionRecordEditDemographics,
+		PermissionPatientRead,
+		PermissionPatientWrite,
+		PermissionPatientHistoryWrite,
+		PermissionVitalsWrite,
+		PermissionAppointmentManage,
+		PermissionQueueManage,
+	},
+	RoleReceptionist: {
+		PermissionPatientRead,
+		PermissionPatientWrite,
+		PermissionAppointmentManage,
+		PermissionQueueManage,
+	},
+}
+
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func PermissionsForRole(role string) []Permission {
+	return rolePermissions[role]
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"log"
+	"medis/helper"
+	"net/http"
+	"os"
+)
+
+/*
+Gateway SMS/WhatsApp tiruan untuk development lokal. Jalankan dengan go run ./cmd/messaging-stub lalu set
+MESSAGING_PROVIDER=http dan MESSAGING_API_URL=http://localhost:8090 pada aplikasi. Pesan yang diterima dicetak ke log
+dan bisa dilihat lewat GET /messages. Alamat diatur lewat MESSAGING_STUB_ADDR dan token lewat MESSAGING_API_KEY.
+*/
+func main() {
+	addr := os.Getenv("MESSAGING_STUB_ADDR")
+	if addr == "" {
+		addr = ":8090"
+	}
+
+	server := &stubServer{
+		APIKey: os.Getenv("MESSAGING_API_KEY"),
+		Received: func(message helper.TextMessage) {
+			log.Printf("[%s] to %s:\n%s\n", message.Channel, message.To, message.Body)
+		},
+	}
+	log.Println("Messaging stub listening on", addr)
+	log.Fatal(http.ListenAndServe(addr, server))
+}
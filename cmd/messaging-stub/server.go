@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"medis/helper"
+	"net/http"
+	"sync"
+)
+
+/*
+stubServer meniru gateway SMS/WhatsApp. Server ini menerima POST /messages dengan format yang sama seperti
+helper.HTTPMessagingProvider, dan GET /messages menampilkan semua pesan yang sudah diterima.
+*/
+type stubServer struct {
+	APIKey   string
+	Received func(message helper.TextMessage)
+	mu       sync.Mutex
+	messages []helper.TextMessage
+}
+
+func (s *stubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/messages" {
+		http.NotFound(w, r)
+		return
+	}
+	if s.APIKey != "" && r.Header.Get("Authorization") != "Bearer "+s.APIKey {
+		http.Error(w, "invalid api key", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.mu.Lock()
+		messages := append([]helper.TextMessage{}, s.messages...)
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(messages)
+	case http.MethodPost:
+		var message helper.TextMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		// Tujuan harus sudah dalam format E.164, persis seperti yang dikirim HTTPMessagingProvider
+		normalized, err := helper.NormalizePhoneNumber(message.To)
+		if !helper.IsMessagingChannel(message.Channel) || err != nil || normalized != message.To || message.Body == "" {
+			http.Error(w, "channel must be sms or whatsapp, to must be E.164 and body must not be empty", http.StatusUnprocessableEntity)
+			return
+		}
+		s.mu.Lock()
+		s.messages = append(s.messages, message)
+		id := len(s.messages)
+		s.mu.Unlock()
+		if s.Received != nil {
+			s.Received(message)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": id, "status": "queued"})
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -44,8 +44,57 @@ func InitializeDatabase() (*gorm.DB, error) {
 	/*
 		Kode untuk migrasi model model object ke dalam basis data menggunakan GORM
 	*/
+	// Dicek sebelum AutoMigrate, karena setelahnya kolom approval_status sudah ada (lihat migrateLegacyDoctorApproval)
+	legacyDoctors := db.Migrator().HasTable(&models.Doctor{}) && !db.Migrator().HasColumn(&models.Doctor{}, "ApprovalStatus")
 	db.AutoMigrate(&models.Doctor{})
 	db.AutoMigrate(&models.MedicalRecords{})
+	db.AutoMigrate(&models.RefreshToken{})
+	db.AutoMigrate(&models.RecoveryCode{})
+	db.AutoMigrate(&models.LoginAttempt{})
+	db.AutoMigrate(&models.Organization{})
+	db.AutoMigrate(&models.Membership{})
+	db.AutoMigrate(&models.OrganizationInvitation{})
+	db.AutoMigrate(&models.Patient{})
+	db.AutoMigrate(&models.ICD10Code{})
+	db.AutoMigrate(&models.MedicalRecordDiagnosis{})
+	db.AutoMigrate(&models.PrescriptionItem{})
+	db.AutoMigrate(&models.VitalSign{})
+	db.AutoMigrate(&models.PatientAllergy{})
+	db.AutoMigrate(&models.PatientProblem{})
+	db.AutoMigrate(&models.DrugInteraction{})
+	db.AutoMigrate(&models.DoctorAvailability{})
+	db.AutoMigrate(&models.DoctorLeave{})
+	db.AutoMigrate(&models.Appointment{})
+	db.AutoMigrate(&models.QueueEntry{})
+	db.AutoMigrate(&models.QueueCounter{})
+	db.AutoMigrate(&models.OutboxEmail{})
+
+	if legacyDoctors {
+		if err := migrateLegacyDoctorApproval(db); err != nil {
+			return nil, err
+		}
+	}
+	if err := migrateDoctorOrganizations(db); err != nil {
+		return nil, err
+	}
+	if err := migrateRecordPatients(db); err != nil {
+		return nil, err
+	}
+	if err := scrubOutboxTokens(db); err != nil {
+		return nil, err
+	}
+	if err := seedICD10Codes(db); err != nil {
+		return nil, err
+	}
+	if err := seedDrugInteractions(db); err != nil {
+		return nil, err
+	}
+	if err := setupAppointmentConstraints(db); err != nil {
+		return nil, err
+	}
+	if err := bootstrapAdmin(db); err != nil {
+		return nil, err
+	}
 
 	return db, nil
 }
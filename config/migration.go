@@ -0,0 +1,234 @@
+package config
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"medis/auth"
+	"medis/helper"
+	"medis/models"
+	"os"
+	"time"
+)
+
+/*
+Membuat admin pertama. Pendaftaran selalu menghasilkan role doctor dan hanya admin yang bisa mengubah role, sehingga
+tanpa langkah ini rute admin tidak bisa dipakai. Akun dengan username BOOTSTRAP_ADMIN_USERNAME dijadikan admin dan
+disetujui setiap kali aplikasi dijalankan, jadi daftarkan akunnya lebih dulu lalu restart aplikasi. Kosongkan
+variabelnya setelah admin pertama ada supaya role akun tersebut bisa diatur kembali lewat API.
+*/
+func bootstrapAdmin(db *gorm.DB) error {
+	username := os.Getenv("BOOTSTRAP_ADMIN_USERNAME")
+	if username == "" {
+		return nil
+	}
+
+	var doctor models.Doctor
+	if err := db.Where("username = ?", username).First(&doctor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("BOOTSTRAP_ADMIN_USERNAME %q is not registered yet, sign up first and restart the application", username)
+			return nil
+		}
+		return err
+	}
+	if doctor.Role == auth.RoleAdmin && doctor.ApprovalStatus == models.ApprovalStatusApproved {
+		return nil
+	}
+
+	now := time.Now()
+	log.Printf("Promoting %q to admin from BOOTSTRAP_ADMIN_USERNAME", username)
+	return db.Model(&doctor).Updates(map[string]interface{}{
+		"role":            auth.RoleAdmin,
+		"approval_status": models.ApprovalStatusApproved,
+		"approved_at":     &now,
+	}).Error
+}
+
+/*
+Migrasi data untuk akun yang dibuat sebelum ada persetujuan izin praktik. Kolom approval_status berisi pending secara
+bawaan sehingga akun baru selalu menunggu persetujuan admin. Akun lama yang sudah aktif sebelum fitur ini ada disetujui
+sekali di sini, hanya saat kolom tersebut baru ditambahkan, supaya dokter yang sudah berjalan tidak tiba-tiba terkunci.
+*/
+func migrateLegacyDoctorApproval(db *gorm.DB) error {
+	log.Println("Approving doctors registered before license approval was introduced")
+	return db.Model(&models.Doctor{}).
+		Where("approval_status = ?", models.ApprovalStatusPending).
+		Updates(map[string]interface{}{
+			"approval_status": models.ApprovalStatusApproved,
+			"approved_at":     time.Now(),
+		}).Error
+}
+
+/*
+Migrasi data untuk outbox yang dibuat sebelum link verifikasi dan reset password dibuat saat pengiriman. Token yang
+masih tersimpan di payload baris lama dihapus, link-nya dibuat ulang oleh worker dari data dokter. Aman dijalankan
+berulang kali karena hanya menyentuh baris yang masih memuat token.
+*/
+func scrubOutboxTokens(db *gorm.DB) error {
+	return db.Model(&models.OutboxEmail{}).
+		Where("payload->>'token' IS NOT NULL").
+		Update("payload", gorm.Expr("payload - 'token'")).Error
+}
+
+/*
+Migrasi data untuk akun yang dibuat sebelum ada fitur klinik. Setiap dokter yang belum menjadi anggota
+klinik mana pun dibuatkan praktik pribadi, lalu rekam medis miliknya yang belum punya organization_id
+dipindahkan ke praktik tersebut. Aman dijalankan berulang kali karena hanya menyentuh data yang belum dimigrasi.
+*/
+func migrateDoctorOrganizations(db *gorm.DB) error {
+	var doctors []models.Doctor
+	err := db.Where("id NOT IN (?)", db.Model(&models.Membership{}).Select("doctor_id")).Find(&doctors).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range doctors {
+		doctor := &doctors[i]
+		err := db.Transaction(func(tx *gorm.DB) error {
+			organization, err := helper.CreatePersonalOrganization(tx, doctor)
+			if err != nil {
+				return err
+			}
+			return tx.Model(&models.MedicalRecords{}).
+				Where("doctor_id = ? AND (organization_id = 0 OR organization_id IS NULL)", doctor.ID).
+				Update("organization_id", organization.ID).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type recordPatientGroup struct {
+	OrganizationID uint
+	PatientName    string
+	BirthDate      string
+	Email          string
+	PhoneNumber    string
+	DoctorID       uint
+}
+
+/*
+Migrasi data untuk rekam medis yang dibuat sebelum ada data pasien. Rekam medis tanpa patient_id di klinik yang sama
+dikelompokkan berdasarkan nama (tanpa membedakan huruf besar/kecil), tanggal lahir, email dan nomor telepon,
+lalu setiap kelompok dijadikan satu pasien. Jika pasien yang cocok sudah ada, rekam medis dihubungkan ke pasien tersebut.
+*/
+func migrateRecordPatients(db *gorm.DB) error {
+	var groups []recordPatientGroup
+	err := db.Model(&models.MedicalRecords{}).
+		Select("organization_id, MIN(TRIM(patient_name)) AS patient_name, birth_date, MIN(TRIM(email)) AS email, TRIM(phone_number) AS phone_number, MIN(doctor_id) AS doctor_id").
+		Where("patient_id = 0 OR patient_id IS NULL").
+		Group("organization_id, LOWER(TRIM(patient_name)), birth_date, LOWER(TRIM(email)), TRIM(phone_number)").
+		Scan(&groups).Error
+	if err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			patient, err := helper.FindMatchingPatient(tx, group.OrganizationID, group.PatientName, group.BirthDate, group.Email, group.PhoneNumber)
+			if err != nil {
+				return err
+			}
+			if patient == nil {
+				patient = &models.Patient{
+					OrganizationID: group.OrganizationID,
+					Name:           group.PatientName,
+					BirthDate:      group.BirthDate,
+					Email:          group.Email,
+					PhoneNumber:    group.PhoneNumber,
+					CreatedByID:    group.DoctorID,
+				}
+				if err := tx.Create(patient).Error; err != nil {
+					return err
+				}
+			}
+
+			return tx.Model(&models.MedicalRecords{}).
+				Where("patient_id = 0 OR patient_id IS NULL").
+				Where("organization_id = ? AND birth_date = ?", group.OrganizationID, group.BirthDate).
+				Where("LOWER(TRIM(patient_name)) = LOWER(?) AND LOWER(TRIM(email)) = LOWER(?) AND TRIM(phone_number) = ?", group.PatientName, group.Email, group.PhoneNumber).
+				Update("patient_id", patient.ID).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Mengisi tabel icd10_codes dari file CSV bawaan (default data/icd10.csv, bisa diganti lewat ICD10_DATA_FILE).
+Kode yang sudah ada hanya diperbarui deskripsinya sehingga file bisa diganti dengan daftar ICD-10 lengkap kapan saja.
+*/
+func seedICD10Codes(db *gorm.DB) error {
+	path := os.Getenv("ICD10_DATA_FILE")
+	if path == "" {
+		path = "data/icd10.csv"
+	}
+
+	codes, err := helper.LoadICD10File(path)
+	if err != nil {
+		return err
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"description"}),
+	}).CreateInBatches(codes, 500).Error
+}
+
+/*
+Mengisi tabel drug_interactions dari file CSV bawaan (default data/drug_interactions.csv, bisa diganti lewat
+DRUG_INTERACTION_DATA_FILE). Isi tabel selalu diganti seluruhnya supaya aturan yang dihapus dari file ikut hilang.
+*/
+func seedDrugInteractions(db *gorm.DB) error {
+	path := os.Getenv("DRUG_INTERACTION_DATA_FILE")
+	if path == "" {
+		path = "data/drug_interactions.csv"
+	}
+
+	interactions, err := helper.LoadDrugInteractionFile(path)
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.DrugInteraction{}).Error; err != nil {
+			return err
+		}
+		if len(interactions) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(interactions, 500).Error
+	})
+}
+
+/*
+Memasang exclusion constraint yang mencegah dua janji temu aktif milik dokter yang sama saling bertumpuk.
+Constraint ini butuh extension btree_gist supaya doctor_id (integer) bisa dipakai di index GiST bersama rentang waktu.
+*/
+func setupAppointmentConstraints(db *gorm.DB) error {
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS btree_gist").Error; err != nil {
+		return err
+	}
+
+	return db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'appointments_valid_range') THEN
+				ALTER TABLE appointments ADD CONSTRAINT appointments_valid_range CHECK (ends_at > starts_at);
+			END IF;
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'appointments_no_overlap') THEN
+				ALTER TABLE appointments ADD CONSTRAINT appointments_no_overlap
+					EXCLUDE USING gist (doctor_id WITH =, tstzrange(starts_at, ends_at) WITH &&)
+					WHERE (status <> 'cancelled');
+			END IF;
+		END
+		$$`).Error
+}
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"context"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"gorm.io/gorm"
 	"log"
+	"medis/appconfig"
+	"medis/helper"
 	"medis/routes"
 )
 
@@ -14,15 +18,43 @@ middleware cors -> digunakan untuk memungkinkan aplikasi untuk berkomunikasi den
 middleware RemoveTrailingSlash -> digunakan untuk menghapus otomatis tanda garis miring (/) di akhir URL yang diminta. Misalnya, jika ada permintaan ke /about/, middleware ini akan secara otomatis mengarahkannya ke /about.
 */
 
-func SetupRouter() *echo.Echo {
+func SetupRouter(ctx context.Context) *echo.Echo {
+	// Konfigurasi dibaca paling awal supaya PUBLIC_BASE_URL yang salah langsung menghentikan aplikasi
+	appConfig, err := appconfig.LoadFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	appconfig.Set(appConfig)
+
 	db, err := InitializeDatabase()
 	if err != nil {
 		log.Fatal(err)
 	}
+	notifier, err := helper.NewNotifierFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	messenger, err := helper.NewMessagingProviderFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return NewRouter(ctx, db, notifier, messenger)
+}
+
+/*
+NewRouter menyusun router dengan database, notifier dan penyedia SMS/WhatsApp yang diberikan. Dengan
+helper.MemoryNotifier dan helper.MemoryMessagingProvider seluruh alur dari pendaftaran sampai rekam medis bisa
+dijalankan tanpa server SMTP maupun gateway pesan lalu notifikasi yang terkirim diperiksa. Worker outbox berhenti
+saat ctx dibatalkan.
+*/
+func NewRouter(ctx context.Context, db *gorm.DB, notifier helper.Notifier, messenger helper.MessagingProvider) *echo.Echo {
+	// Worker outbox mengirim email dan pesan yang diantrekan oleh request di latar belakang
+	go helper.RunOutboxWorker(ctx, db, notifier, messenger)
+
 	router := echo.New()
 	router.Use(middleware.Logger())
 	router.Use(middleware.CORS())
 	router.Pre(middleware.RemoveTrailingSlash())
-	routes.SetupRoutes(router, db)
+	routes.SetupRoutes(router, db, notifier, messenger)
 	return router
 }
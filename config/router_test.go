@@ -0,0 +1,180 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"medis/appconfig"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var verificationLinkPattern = regexp.MustCompile(`https?://\S+/verify\?token=\S+`)
+
+/*
+TestSignupToMedicalRecordFlow menjalankan alur pendaftaran dokter, verifikasi email dan pembuatan rekam medis lewat
+router lengkap dengan helper.MemoryNotifier, lalu memeriksa email yang terkirim. Pengujian ini butuh database
+Postgres dari DB_HOST, DB_PORT, DB_USERNAME, DB_PASSWORD dan DB_NAME, dan dilewati jika DB_HOST kosong.
+*/
+func TestSignupToMedicalRecordFlow(t *testing.T) {
+	if os.Getenv("DB_HOST") == "" {
+		t.Skip("DB_HOST is not set, skipping flow test that needs Postgres")
+	}
+	t.Setenv("PUBLIC_BASE_URL", "http://localhost:8080")
+	t.Setenv("JWT_KEYS_DIR", writeTestSigningKey(t))
+	t.Setenv("JWT_SIGNING_KEY_ID", "test")
+	t.Setenv("ICD10_DATA_FILE", "../data/icd10.csv")
+	t.Setenv("DRUG_INTERACTION_DATA_FILE", "../data/drug_interactions.csv")
+	// Worker latar belakang tidak ikut mengirim, outbox dikosongkan langsung lewat ProcessOutboxBatch
+	t.Setenv("OUTBOX_POLL_INTERVAL", "3600")
+
+	appConfig, err := appconfig.LoadFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	appconfig.Set(appConfig)
+	db, err := InitializeDatabase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifier := helper.NewMemoryNotifier()
+	messenger := helper.NewMemoryMessagingProvider()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	router := NewRouter(ctx, db, notifier, messenger)
+
+	flushOutbox := func() {
+		t.Helper()
+		if _, err := helper.ProcessOutboxBatch(db, notifier, messenger, helper.DefaultOutboxMaxAttempts, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Pendaftaran mengirim email selamat datang berisi link verifikasi
+	suffix := time.Now().UnixNano()
+	doctorEmail := fmt.Sprintf("doctor%d@example.com", suffix)
+	code, body := doJSON(t, router, http.MethodPost, "/api/doctor/signup", "", map[string]interface{}{
+		"first_name":          "Flow",
+		"last_name":           "Test",
+		"contact_number":      "081234567890",
+		"gender":              "male",
+		"email":               doctorEmail,
+		"username":            fmt.Sprintf("flowtest%d", suffix),
+		"password":            "secret123",
+		"str_number":          fmt.Sprintf("STR%d", suffix),
+		"sip_number":          fmt.Sprintf("SIP%d", suffix),
+		"specialty":           "General Practice",
+		"license_expiry_date": time.Now().AddDate(1, 0, 0).Format("2006-01-02"),
+	})
+	if code != http.StatusOK {
+		t.Fatalf("signup returned %d: %v", code, body)
+	}
+	accessToken, _ := body["token"].(string)
+	doctorID, _ := body["id"].(float64)
+
+	flushOutbox()
+	welcome := notifier.MessagesTo(doctorEmail)
+	if len(welcome) != 1 {
+		t.Fatalf("expected 1 welcome email, got %d", len(welcome))
+	}
+	link := verificationLinkPattern.FindString(welcome[0].TextBody)
+	if link == "" {
+		t.Fatalf("welcome email has no verification link: %q", welcome[0].TextBody)
+	}
+	verifyURL, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, body = doJSON(t, router, http.MethodGet, verifyURL.RequestURI(), "", nil)
+	if code != http.StatusOK {
+		t.Fatalf("verification returned %d: %v", code, body)
+	}
+
+	// Persetujuan izin praktik oleh admin klinik di luar cakupan alur ini
+	if err := db.Model(&models.Doctor{}).Where("id = ?", uint(doctorID)).Update("approval_status", models.ApprovalStatusApproved).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Rekam medis baru mengirim ringkasan kunjungan beserta PDF ke email pasien
+	patientEmail := fmt.Sprintf("patient%d@example.com", suffix)
+	code, body = doJSON(t, router, http.MethodPost, "/api/doctor/medical-record", accessToken, map[string]interface{}{
+		"patient_name":    "Flow Patient",
+		"birth_date":      "1990-01-01",
+		"email":           patientEmail,
+		"phone_number":    "081298765432",
+		"diagnosis":       "Common cold",
+		"prescription":    "Paracetamol 500 mg three times a day",
+		"care_suggestion": "Rest and drink plenty of water",
+	})
+	if code != http.StatusCreated {
+		t.Fatalf("adding medical record returned %d: %v", code, body)
+	}
+
+	flushOutbox()
+	summaries := notifier.MessagesTo(patientEmail)
+	if len(summaries) != 1 {
+		t.Fatalf("expected 1 medical record email, got %d", len(summaries))
+	}
+	if len(summaries[0].Attachments) != 1 {
+		t.Fatalf("expected the medical record PDF attachment, got %d attachments", len(summaries[0].Attachments))
+	}
+	if got := len(notifier.MessagesTo(doctorEmail)); got != 1 {
+		t.Fatalf("expected only the welcome email to the doctor, got %d", got)
+	}
+}
+
+// doJSON mengirim request ke router dan mengembalikan status serta body JSON-nya
+func doJSON(t *testing.T, router http.Handler, method, target, accessToken string, payload interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	var requestBody bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&requestBody).Encode(payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	request := httptest.NewRequest(method, target, &requestBody)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		request.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	var body map[string]interface{}
+	json.Unmarshal(recorder.Body.Bytes(), &body)
+	return recorder.Code, body
+}
+
+// writeTestSigningKey membuat kunci Ed25519 sementara untuk menandatangani JWT selama pengujian
+func writeTestSigningKey(t *testing.T) string {
+	t.Helper()
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, "test.pem"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"medis/auth"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+	"strconv"
+)
+
+// organizationDoctors membatasi query dokter pada anggota klinik aktif admin, supaya admin satu klinik tidak bisa
+// melihat atau mengubah akun dari klinik lain
+func organizationDoctors(db *gorm.DB, organizationID uint) *gorm.DB {
+	return db.Model(&models.Doctor{}).
+		Where("id IN (?)", db.Model(&models.Membership{}).Select("doctor_id").Where("organization_id = ?", organizationID))
+}
+
+/*
+checkAdministeredDoctor menolak perubahan role atau status persetujuan dokter yang juga menjadi anggota klinik lain
+yang tidak diikuti admin. Role dan status persetujuan berlaku global untuk semua klinik dokter tersebut, sehingga
+admin hanya boleh mengubahnya jika semua klinik dokter itu juga kliniknya. Praktik pribadi dokter itu sendiri tidak
+dihitung.
+*/
+func checkAdministeredDoctor(db *gorm.DB, admin *models.Doctor, doctor *models.Doctor) *helper.ErrorResponse {
+	var count int64
+	err := db.Model(&models.Membership{}).
+		Where("doctor_id = ?", doctor.ID).
+		Where("organization_id NOT IN (?)", db.Model(&models.Membership{}).Select("organization_id").Where("doctor_id = ?", admin.ID)).
+		Where("organization_id NOT IN (?)", db.Model(&models.Organization{}).Select("id").Where("is_personal = ? AND created_by_id = ?", true, doctor.ID)).
+		Count(&count).Error
+	if err != nil {
+		return &helper.ErrorResponse{Code: http.StatusInternalServerError, Message: "Failed to check user memberships"}
+	}
+	if count > 0 {
+		return &helper.ErrorResponse{Code: http.StatusForbidden, Message: "This user also belongs to a clinic you do not administer"}
+	}
+	return nil
+}
+
+func ListUsers(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := c.Get("claims").(*auth.Claims)
+
+		page, err := strconv.Atoi(c.QueryParam("page"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+
+		limit, err := strconv.Atoi(c.QueryParam("limit"))
+		if err != nil || limit < 1 {
+			limit = 10
+		}
+
+		query := organizationDoctors(db, claims.OrganizationID)
+		if role := c.QueryParam("role"); role != "" {
+			query = query.Where("role = ?", role)
+		}
+		if approvalStatus := c.QueryParam("approval_status"); approvalStatus != "" {
+			query = query.Where("approval_status = ?", approvalStatus)
+		}
+
+		var totalRecords int64
+		query.Count(&totalRecords)
+
+		var doctors []models.Doctor
+		if err := query.Order("id ASC").Offset((page - 1) * limit).Limit(limit).Find(&doctors).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch users",
+			})
+		}
+
+		users := make([]map[string]interface{}, 0, len(doctors))
+		for _, doctor := range doctors {
+			users = append(users, doctorProfile(doctor))
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":         http.StatusOK,
+			"error":        false,
+			"message":      "Users fetched successfully",
+			"data":         users,
+			"totalRecords": totalRecords,
+			"page":         page,
+			"limit":        limit,
+		})
+	}
+}
+
+func UpdateUserRole(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		admin := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		var request helper.UpdateRoleRequest
+		if err := c.Bind(&request); err != nil || !auth.IsValidRole(request.Role) {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Role must be one of admin, doctor, nurse or receptionist",
+			})
+		}
+
+		var doctor models.Doctor
+		if err := organizationDoctors(db, claims.OrganizationID).First(&doctor, c.Param("id")).Error; err != nil {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: "User not found",
+			})
+		}
+
+		// Admin tidak boleh menurunkan role miliknya sendiri agar klinik tidak kehilangan admin terakhir
+		if doctor.ID == admin.ID {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "You cannot change your own role",
+			})
+		}
+
+		if errorResponse := checkAdministeredDoctor(db, admin, &doctor); errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		if err := db.Model(&doctor).Update("role", request.Role).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to update role",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "User role updated successfully",
+			"data":    doctorProfile(doctor),
+		})
+	}
+}
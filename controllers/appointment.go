@@ -0,0 +1,388 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"medis/auth"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// scopeAppointments membatasi query janji temu ke klinik aktif, atau ke dokter itu sendiri tanpa klinik
+func scopeAppointments(query *gorm.DB, doctor *models.Doctor, claims *auth.Claims) *gorm.DB {
+	if claims.OrganizationID == 0 {
+		return query.Where("doctor_id = ?", doctor.ID)
+	}
+	return query.Where("organization_id = ?", claims.OrganizationID)
+}
+
+func BookAppointment(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+		request := c.Get("appointment").(helper.AppointmentRequest)
+
+		if errorResponse := checkAppointmentDoctor(db, doctor, claims, request.DoctorID); errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		var patient models.Patient
+		if err := scopePatients(db, doctor, claims).Where("id = ?", request.PatientID).First(&patient).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+					Code:    http.StatusNotFound,
+					Message: "Patient not found",
+				})
+			}
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch patient",
+			})
+		}
+
+		slot, err := helper.ResolveAppointmentSlot(db, request.DoctorID, claims.OrganizationID, request.StartsAt, time.Now())
+		if err != nil {
+			return appointmentSlotErrorResponse(c, err)
+		}
+
+		appointment := models.Appointment{
+			OrganizationID: claims.OrganizationID,
+			DoctorID:       request.DoctorID,
+			PatientID:      patient.ID,
+			StartsAt:       slot.StartsAt,
+			EndsAt:         slot.EndsAt,
+			Status:         models.AppointmentStatusScheduled,
+			Reason:         request.Reason,
+			CreatedByID:    doctor.ID,
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&appointment).Error; err != nil {
+				return err
+			}
+			return helper.ScheduleAppointmentReminder(tx, &appointment, &patient, time.Now())
+		})
+		if err != nil {
+			return appointmentSaveErrorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusCreated, map[string]interface{}{
+			"code":    http.StatusCreated,
+			"error":   false,
+			"message": "Appointment booked successfully",
+			"data":    appointment,
+		})
+	}
+}
+
+// GetAppointments mendukung filter date (yyyy-mm-dd, zona waktu klinik), doctor_id, patient_id dan status
+func GetAppointments(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		page, err := strconv.Atoi(c.QueryParam("page"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+
+		limit, err := strconv.Atoi(c.QueryParam("limit"))
+		if err != nil || limit < 1 {
+			limit = 10
+		}
+
+		query := scopeAppointments(db.Model(&models.Appointment{}), doctor, claims)
+		if date := c.QueryParam("date"); date != "" {
+			dayStart, err := time.ParseInLocation("2006-01-02", date, helper.ClinicLocation())
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Date must be in the format yyyy-mm-dd",
+				})
+			}
+			query = query.Where("starts_at >= ? AND starts_at < ?", dayStart, dayStart.AddDate(0, 0, 1))
+		}
+		if doctorID := c.QueryParam("doctor_id"); doctorID != "" {
+			query = query.Where("doctor_id = ?", doctorID)
+		}
+		if patientID := c.QueryParam("patient_id"); patientID != "" {
+			query = query.Where("patient_id = ?", patientID)
+		}
+		if status := c.QueryParam("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+
+		var totalRecords int64
+		query.Count(&totalRecords)
+
+		var appointments []models.Appointment
+		if err := query.Order("starts_at ASC").Offset((page - 1) * limit).Limit(limit).Find(&appointments).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch appointments",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":         http.StatusOK,
+			"error":        false,
+			"message":      "Appointments fetched successfully",
+			"data":         appointments,
+			"totalRecords": totalRecords,
+			"page":         page,
+			"limit":        limit,
+		})
+	}
+}
+
+func GetAppointmentByID(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		appointment, errorResponse := findAppointment(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Appointment fetched successfully",
+			"data":    appointment,
+		})
+	}
+}
+
+func RescheduleAppointment(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		var request helper.RescheduleAppointmentRequest
+		if err := c.Bind(&request); err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body. starts_at must be an RFC 3339 timestamp",
+			})
+		}
+
+		appointment, errorResponse := findAppointment(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+		if appointment.Status != models.AppointmentStatusScheduled {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: "Only scheduled appointments can be rescheduled",
+			})
+		}
+
+		slot, err := helper.ResolveAppointmentSlot(db, appointment.DoctorID, appointment.OrganizationID, request.StartsAt, time.Now())
+		if err != nil {
+			return appointmentSlotErrorResponse(c, err)
+		}
+
+		var patient models.Patient
+		if err := db.First(&patient, appointment.PatientID).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch patient",
+			})
+		}
+
+		appointment.StartsAt = slot.StartsAt
+		appointment.EndsAt = slot.EndsAt
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(appointment).Error; err != nil {
+				return err
+			}
+			return helper.ScheduleAppointmentReminder(tx, appointment, &patient, time.Now())
+		})
+		if err != nil {
+			return appointmentSaveErrorResponse(c, err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Appointment rescheduled successfully",
+			"data":    appointment,
+		})
+	}
+}
+
+// CancelAppointment membatalkan janji temu. Slot yang dibatalkan langsung bisa dipesan lagi.
+func CancelAppointment(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		var request helper.CancelAppointmentRequest
+		if err := c.Bind(&request); err != nil || len(request.Reason) > 255 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body. Reason must be at most 255 characters",
+			})
+		}
+
+		appointment, errorResponse := findAppointment(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+		if appointment.Status != models.AppointmentStatusScheduled {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: "Only scheduled appointments can be cancelled",
+			})
+		}
+
+		appointment.Status = models.AppointmentStatusCancelled
+		appointment.CancelReason = request.Reason
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(appointment).Error; err != nil {
+				return err
+			}
+			return helper.CancelAppointmentReminders(tx, appointment.ID)
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to cancel appointment",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Appointment cancelled successfully",
+			"data":    appointment,
+		})
+	}
+}
+
+// CompleteAppointment menandai janji temu selesai dan menautkannya ke rekam medis yang dibuat dari kunjungan tersebut
+func CompleteAppointment(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		var request helper.CompleteAppointmentRequest
+		if err := c.Bind(&request); err != nil || request.MedicalRecordID == 0 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Medical record ID is required",
+			})
+		}
+
+		appointment, errorResponse := findAppointment(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+		if appointment.Status != models.AppointmentStatusScheduled {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: "Only scheduled appointments can be completed",
+			})
+		}
+
+		var medicalRecord models.MedicalRecords
+		if err := scopeMedicalRecords(db, doctor, claims).Where("id = ?", request.MedicalRecordID).First(&medicalRecord).Error; err != nil {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: "Medical record not found",
+			})
+		}
+		if medicalRecord.PatientID != appointment.PatientID {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Medical record belongs to a different patient",
+			})
+		}
+		if medicalRecord.AppointmentID != nil {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: "Medical record is already linked to an appointment",
+			})
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return completeAppointment(tx, appointment, medicalRecord.ID)
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to complete appointment",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Appointment completed successfully",
+			"data": map[string]interface{}{
+				"appointment":       appointment,
+				"medical_record_id": medicalRecord.ID,
+			},
+		})
+	}
+}
+
+// completeAppointment dipanggil di dalam transaksi, baik dari CompleteAppointment maupun saat rekam medis dibuat
+func completeAppointment(tx *gorm.DB, appointment *models.Appointment, medicalRecordID uint) error {
+	if err := tx.Model(&models.MedicalRecords{}).Where("id = ?", medicalRecordID).Update("appointment_id", appointment.ID).Error; err != nil {
+		return err
+	}
+	appointment.Status = models.AppointmentStatusCompleted
+	if err := tx.Save(appointment).Error; err != nil {
+		return err
+	}
+	return helper.CancelAppointmentReminders(tx, appointment.ID)
+}
+
+func findAppointment(c echo.Context, db *gorm.DB, doctor *models.Doctor, claims *auth.Claims) (*models.Appointment, *helper.ErrorResponse) {
+	appointmentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, &helper.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid appointment ID"}
+	}
+
+	var appointment models.Appointment
+	if err := scopeAppointments(db, doctor, claims).Where("id = ?", appointmentID).First(&appointment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &helper.ErrorResponse{Code: http.StatusNotFound, Message: "Appointment not found"}
+		}
+		return nil, &helper.ErrorResponse{Code: http.StatusInternalServerError, Message: "Failed to fetch appointment"}
+	}
+	return &appointment, nil
+}
+
+func appointmentSlotErrorResponse(c echo.Context, err error) error {
+	var invalidAppointment *helper.InvalidAppointmentError
+	if errors.As(err, &invalidAppointment) {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+		Code:    http.StatusInternalServerError,
+		Message: "Failed to check doctor availability",
+	})
+}
+
+// appointmentSaveErrorResponse mengubah penolakan exclusion constraint menjadi 409
+func appointmentSaveErrorResponse(c echo.Context, err error) error {
+	if helper.IsAppointmentConflict(err) {
+		return c.JSON(http.StatusConflict, helper.ErrorResponse{
+			Code:    http.StatusConflict,
+			Message: "The selected slot is already booked",
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+		Code:    http.StatusInternalServerError,
+		Message: "Failed to save appointment",
+	})
+}
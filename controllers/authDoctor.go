@@ -1,45 +1,164 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/labstack/echo/v4"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 	"html/template"
+	"log"
 	"medis/auth"
 	"medis/helper"
 	"medis/models"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Status hasil verifikasi email, dikirim apa adanya pada response JSON
+const (
+	verificationStatusVerified    = "verified"
+	verificationStatusExpired     = "expired"
+	verificationStatusAlreadyUsed = "already_used"
+	verificationStatusUnknown     = "unknown"
+)
+
+type verificationPage struct {
+	Status  string
+	Title   string
+	Message string
+	Success bool
+}
+
 func VerifyEmail(db *gorm.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		token := c.QueryParam("token")
 
 		var doctor models.Doctor
 		result := db.Where("verification_token = ?", token).First(&doctor)
-		if result.Error != nil {
-			return c.String(http.StatusUnauthorized, "Invalid verification token")
+		switch {
+		case token == "" || result.Error != nil:
+			return renderVerification(c, http.StatusUnauthorized, verificationPage{
+				Status:  verificationStatusUnknown,
+				Title:   "Invalid verification link",
+				Message: "This verification link is not valid. Please request a new verification email.",
+			})
+		case doctor.VerificationUsedAt != nil:
+			return renderVerification(c, http.StatusConflict, verificationPage{
+				Status:  verificationStatusAlreadyUsed,
+				Title:   "Email already verified",
+				Message: "This verification link has already been used. You can log in to your account.",
+			})
+		case doctor.VerificationExpiresAt == nil || time.Now().After(*doctor.VerificationExpiresAt):
+			return renderVerification(c, http.StatusGone, verificationPage{
+				Status:  verificationStatusExpired,
+				Title:   "Verification link expired",
+				Message: "This verification link has expired. Please request a new verification email.",
+			})
 		}
 
-		doctor.IsVerified = true
-		doctor.VerificationToken = ""
-		db.Save(&doctor)
+		// Token dari alur ganti email: alamat baru baru dipakai setelah diverifikasi
+		if doctor.PendingEmail != "" {
+			var existingDoctor models.Doctor
+			if err := db.Where("email = ? AND id <> ?", doctor.PendingEmail, doctor.ID).First(&existingDoctor).Error; err == nil {
+				return renderVerification(c, http.StatusConflict, verificationPage{
+					Status:  verificationStatusUnknown,
+					Title:   "Email already in use",
+					Message: "This email address is already used by another account.",
+				})
+			}
+			doctor.Email = doctor.PendingEmail
+			doctor.PendingEmail = ""
+		}
 
-		tmpl, err := template.ParseFiles("helper/verification.html")
-		if err != nil {
+		now := time.Now()
+		doctor.IsVerified = true
+		doctor.VerificationUsedAt = &now
+		if err := db.Save(&doctor).Error; err != nil {
 			return c.String(http.StatusInternalServerError, "Internal Server Error")
 		}
 
-		err = tmpl.Execute(c.Response().Writer, nil)
+		return renderVerification(c, http.StatusOK, verificationPage{
+			Status:  verificationStatusVerified,
+			Title:   "Email verification successful",
+			Message: "You can now log in to your account.",
+			Success: true,
+		})
+	}
+}
+
+// renderVerification mengirim JSON jika client memintanya lewat header Accept, selain itu halaman HTML
+func renderVerification(c echo.Context, code int, page verificationPage) error {
+	if strings.Contains(c.Request().Header.Get(echo.HeaderAccept), echo.MIMEApplicationJSON) {
+		return c.JSON(code, map[string]interface{}{
+			"code":    code,
+			"error":   !page.Success,
+			"status":  page.Status,
+			"message": page.Message,
+		})
+	}
+
+	tmpl, err := template.ParseFiles("helper/verification.html")
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	c.Response().WriteHeader(code)
+	return tmpl.Execute(c.Response().Writer, page)
+}
+
+func ResendVerificationEmail(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		request := c.Get("resendVerification").(helper.ResendVerificationRequest)
+
+		// Response disamakan untuk email yang tidak terdaftar atau sudah terverifikasi
+		successResponse := map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "If the email is registered and not yet verified, a new verification link has been sent",
+		}
+
+		var doctor models.Doctor
+		if err := db.Where("email = ?", request.Email).First(&doctor).Error; err != nil || doctor.IsVerified {
+			return c.JSON(http.StatusOK, successResponse)
+		}
+
+		if doctor.VerificationSentAt != nil {
+			retryAt := doctor.VerificationSentAt.Add(helper.VerificationResendCooldown)
+			if wait := time.Until(retryAt); wait > 0 {
+				c.Response().Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+				return c.JSON(http.StatusTooManyRequests, helper.ErrorResponse{
+					Code:    http.StatusTooManyRequests,
+					Message: "Verification email was sent recently. Please wait before requesting another one",
+				})
+			}
+		}
+
+		helper.NewVerificationToken(&doctor)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(&doctor).Error; err != nil {
+				return err
+			}
+			return helper.EnqueueEmail(tx, models.EmailKindWelcome, doctor.Email, helper.EmailPayload{
+				Name:     doctor.FirstName + " " + doctor.LastName,
+				DoctorID: doctor.ID,
+				Locale:   doctor.Language,
+			})
+		})
 		if err != nil {
-			return c.String(http.StatusInternalServerError, "Internal Server Error")
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to create verification token",
+			})
 		}
 
-		return nil
+		return c.JSON(http.StatusOK, successResponse)
 	}
 }
 
-func RegisterDoctor(db *gorm.DB, secretKey []byte) echo.HandlerFunc {
+func RegisterDoctor(db *gorm.DB, keys *auth.KeySet) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		doctor := c.Get("doctor").(models.Doctor)
 
@@ -53,11 +172,33 @@ func RegisterDoctor(db *gorm.DB, secretKey []byte) echo.HandlerFunc {
 
 		doctor.Password = string(hashedPassword)
 		doctor.Fullname = doctor.FirstName + " " + doctor.LastName
-		db.Create(&doctor)
+
+		// Setiap dokter baru mendapat praktik pribadi supaya rekam medisnya selalu terikat ke sebuah organisasi
+		var organization *models.Organization
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&doctor).Error; err != nil {
+				return err
+			}
+			organization, err = helper.CreatePersonalOrganization(tx, &doctor)
+			if err != nil {
+				return err
+			}
+			return helper.EnqueueEmail(tx, models.EmailKindWelcome, doctor.Email, helper.EmailPayload{
+				Name:     doctor.Fullname,
+				DoctorID: doctor.ID,
+				Locale:   doctor.Language,
+			})
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to register doctor account",
+			})
+		}
 
 		doctor.Password = ""
 
-		tokenString, err := auth.GenerateToken(doctor.Username, secretKey)
+		tokens, err := helper.IssueDoctorSession(db, &doctor, organization.ID, keys)
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
 				Code:    http.StatusInternalServerError,
@@ -66,22 +207,63 @@ func RegisterDoctor(db *gorm.DB, secretKey []byte) echo.HandlerFunc {
 		}
 
 		response := map[string]interface{}{
-			"code":    http.StatusOK,
-			"message": "Doctor account registered successfully",
-			"token":   tokenString,
-			"id":      doctor.ID,
+			"code":          http.StatusOK,
+			"message":       "Doctor account registered successfully",
+			"token":         tokens.AccessToken,
+			"refresh_token": tokens.RefreshToken,
+			"expires_in":    tokens.ExpiresIn,
+			"id":            doctor.ID,
 		}
 
 		return c.JSON(http.StatusOK, response)
 	}
 }
 
-func SignInDoctor(db *gorm.DB, secretKey []byte) echo.HandlerFunc {
+func SignInDoctor(db *gorm.DB, keys *auth.KeySet) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		existingDoctor := c.Get("doctor").(models.Doctor)
 
+		// Jika 2FA aktif, langkah password hanya menghasilkan challenge token. JWT akhir
+		// baru diterbitkan setelah ValidateTwoFactorSignIn memverifikasi kode TOTP.
+		if existingDoctor.TOTPEnabled {
+			if verified, _ := c.Get("mfaVerified").(bool); !verified {
+				challengeToken, err := auth.GenerateChallengeToken(existingDoctor.Username, keys)
+				if err != nil {
+					return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+						Code:    http.StatusInternalServerError,
+						Message: "Failed to generate token",
+					})
+				}
+
+				return c.JSON(http.StatusOK, map[string]interface{}{
+					"code":            http.StatusOK,
+					"error":           false,
+					"message":         "Two-factor authentication required",
+					"mfa_required":    true,
+					"challenge_token": challengeToken,
+					"expires_in":      int64(auth.ChallengeTokenDuration.Seconds()),
+				})
+			}
+		}
+
+		// Pilih klinik aktif, dari organization_id pada request atau klinik pertama milik dokter
+		requestedOrganizationID, _ := c.Get("organizationID").(uint)
+		organizationID, err := helper.ResolveOrganizationID(db, existingDoctor.ID, requestedOrganizationID)
+		if err != nil {
+			if errors.Is(err, helper.ErrNotOrganizationMember) {
+				return c.JSON(http.StatusForbidden, helper.ErrorResponse{
+					Code:    http.StatusForbidden,
+					Message: err.Error(),
+				})
+			}
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to check organization membership",
+			})
+		}
+
 		// Generate Token
-		tokenString, err := auth.GenerateToken(existingDoctor.Username, secretKey)
+		tokens, err := helper.IssueDoctorSession(db, &existingDoctor, organizationID, keys)
 		if err != nil {
 			errorResponse := helper.ErrorResponse{
 				Code:    http.StatusInternalServerError,
@@ -90,11 +272,87 @@ func SignInDoctor(db *gorm.DB, secretKey []byte) echo.HandlerFunc {
 			return c.JSON(http.StatusInternalServerError, errorResponse)
 		}
 
+		// Notifikasi login baru dikirim setelah sesi benar-benar dibuat, termasuk setelah langkah 2FA berhasil
+		if err := helper.EnqueueEmail(db, models.EmailKindLoginNotification, existingDoctor.Email, helper.EmailPayload{
+			Name:   existingDoctor.FirstName + " " + existingDoctor.LastName,
+			Locale: existingDoctor.Language,
+		}); err != nil {
+			log.Println("Failed to queue login notification email:", err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":            http.StatusOK,
+			"error":           false,
+			"message":         "Doctor login successful",
+			"token":           tokens.AccessToken,
+			"refresh_token":   tokens.RefreshToken,
+			"expires_in":      tokens.ExpiresIn,
+			"organization_id": organizationID,
+			"id":              existingDoctor.ID})
+	}
+}
+
+func RefreshDoctorToken(db *gorm.DB, keys *auth.KeySet) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var request helper.RefreshTokenRequest
+		if err := c.Bind(&request); err != nil || request.RefreshToken == "" {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Refresh token is required",
+			})
+		}
+
+		tokens, err := helper.RotateRefreshToken(db, request.RefreshToken, keys)
+		if err != nil {
+			if errors.Is(err, helper.ErrInvalidRefreshToken) {
+				return c.JSON(http.StatusUnauthorized, helper.ErrorResponse{
+					Code:    http.StatusUnauthorized,
+					Message: err.Error(),
+				})
+			}
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to refresh token",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":          http.StatusOK,
+			"error":         false,
+			"message":       "Token refreshed successfully",
+			"token":         tokens.AccessToken,
+			"refresh_token": tokens.RefreshToken,
+			"expires_in":    tokens.ExpiresIn,
+		})
+	}
+}
+
+func SignOutDoctor(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		// Body boleh kosong, secara default hanya sesi saat ini yang dicabut
+		var request helper.SignOutRequest
+		_ = c.Bind(&request)
+
+		var err error
+		if request.All {
+			err = helper.RevokeAllSessions(db, doctor.ID)
+		} else {
+			err = helper.RevokeSession(db, claims.SessionID)
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to sign out",
+			})
+		}
+
 		return c.JSON(http.StatusOK, map[string]interface{}{
 			"code":    http.StatusOK,
 			"error":   false,
-			"message": "Doctor login successful",
-			"token":   tokenString,
-			"id":      existingDoctor.ID})
+			"message": "Doctor signed out successfully",
+		})
 	}
 }
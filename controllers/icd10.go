@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SearchICD10 dipakai untuk autocomplete kode diagnosis. Kode yang diawali q ditampilkan lebih dulu,
+// lalu kode yang deskripsinya mengandung q.
+func SearchICD10(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		q := strings.TrimSpace(c.QueryParam("q"))
+		if q == "" {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Query parameter q is required",
+			})
+		}
+
+		limit, err := strconv.Atoi(c.QueryParam("limit"))
+		if err != nil || limit < 1 || limit > 50 {
+			limit = 20
+		}
+
+		// q di-escape supaya % dan _ yang diketik pengguna tidak dianggap wildcard
+		codePattern := helper.EscapeLikePattern(helper.NormalizeICD10Code(q)) + "%"
+		var codes []models.ICD10Code
+		err = db.Where("code LIKE ? OR description ILIKE ?", codePattern, "%"+helper.EscapeLikePattern(q)+"%").
+			Order(clause.OrderBy{Expression: clause.Expr{SQL: "CASE WHEN code LIKE ? THEN 0 ELSE 1 END, code ASC", Vars: []interface{}{codePattern}}}).
+			Limit(limit).
+			Find(&codes).Error
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to search ICD-10 codes",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "ICD-10 codes fetched successfully",
+			"data":    codes,
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package controllers
+
+import (
+	"github.com/labstack/echo/v4"
+	"medis/auth"
+	"net/http"
+)
+
+// GetJWKS mempublikasikan public key JWT agar service internal lain bisa memverifikasi token medis
+// tanpa berbagi secret. Kunci lama tetap dicantumkan selama masih ada token yang ditandatangani dengannya.
+func GetJWKS(keys *auth.KeySet) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set("Cache-Control", "public, max-age=300")
+		return c.JSON(http.StatusOK, keys.JWKS())
+	}
+}
@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"medis/auth"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+	"time"
+)
+
+// UpdateLicense dipakai dokter untuk memperpanjang atau memperbaiki data izin praktik.
+// Setiap perubahan mengembalikan akun ke status pending sampai admin menyetujui lagi.
+func UpdateLicense(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		request := c.Get("license").(helper.LicenseRequest)
+
+		var existingDoctor models.Doctor
+		if err := db.Where("str_number = ? AND id <> ?", request.STRNumber, doctor.ID).First(&existingDoctor).Error; err == nil {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: "STR number already registered",
+			})
+		}
+
+		doctor.STRNumber = request.STRNumber
+		doctor.SIPNumber = request.SIPNumber
+		doctor.Specialty = request.Specialty
+		doctor.LicenseExpiryDate = request.LicenseExpiryDate
+		doctor.ApprovalStatus = models.ApprovalStatusPending
+		doctor.ApprovedAt = nil
+		doctor.ApprovedByID = nil
+		doctor.RejectionReason = ""
+
+		if err := db.Save(doctor).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to update license",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "License submitted and waiting for admin approval",
+			"data":    doctorProfile(*doctor),
+		})
+	}
+}
+
+func ApproveDoctor(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		admin := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		var doctor models.Doctor
+		if err := organizationDoctors(db, claims.OrganizationID).First(&doctor, c.Param("id")).Error; err != nil {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: "Doctor not found",
+			})
+		}
+
+		if errorResponse := checkAdministeredDoctor(db, admin, &doctor); errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		if doctor.ApprovalStatus == models.ApprovalStatusApproved {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: "Doctor is already approved",
+			})
+		}
+
+		if helper.IsLicenseExpired(doctor.LicenseExpiryDate, time.Now()) {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Cannot approve a doctor whose license has expired",
+			})
+		}
+
+		now := time.Now()
+		doctor.ApprovalStatus = models.ApprovalStatusApproved
+		doctor.ApprovedAt = &now
+		doctor.ApprovedByID = &admin.ID
+		doctor.RejectionReason = ""
+		if err := db.Save(&doctor).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to approve doctor",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Doctor approved successfully",
+			"data":    doctorProfile(doctor),
+		})
+	}
+}
+
+func RejectDoctor(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var request helper.RejectDoctorRequest
+		if err := c.Bind(&request); err != nil || len(request.Reason) < 1 || len(request.Reason) > 500 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Reason must be between 1 and 500 characters",
+			})
+		}
+
+		admin := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		var doctor models.Doctor
+		if err := organizationDoctors(db, claims.OrganizationID).First(&doctor, c.Param("id")).Error; err != nil {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: "Doctor not found",
+			})
+		}
+
+		if errorResponse := checkAdministeredDoctor(db, admin, &doctor); errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		doctor.ApprovalStatus = models.ApprovalStatusRejected
+		doctor.ApprovedAt = nil
+		doctor.ApprovedByID = nil
+		doctor.RejectionReason = request.Reason
+		if err := db.Save(&doctor).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to reject doctor",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Doctor rejected",
+			"data":    doctorProfile(doctor),
+		})
+	}
+}
@@ -4,21 +4,90 @@ import (
 	"errors"
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
+	"medis/auth"
 	"medis/helper"
 	"medis/models"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
 func AddMedicalRecord(db *gorm.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		doctor := c.Get("doctor").(models.Doctor)
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
 		medicalRecord := c.Get("medicalRecord").(models.MedicalRecords)
 
+		// Rekam medis baru hanya boleh dibuat oleh akun yang sudah disetujui admin dengan izin praktik yang masih berlaku
+		if doctor.ApprovalStatus != models.ApprovalStatusApproved {
+			errorResponse := helper.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: "Your account is waiting for license approval by an admin",
+			}
+			return c.JSON(http.StatusForbidden, errorResponse)
+		}
+		if helper.IsLicenseExpired(doctor.LicenseExpiryDate, time.Now()) {
+			errorResponse := helper.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: "Your practice license has expired. Please submit a renewed license",
+			}
+			return c.JSON(http.StatusForbidden, errorResponse)
+		}
+
+		appointment, errorResponse := resolveRecordAppointment(db, doctor, claims, &medicalRecord)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		patient, errorResponse := resolveRecordPatient(db, doctor, claims, &medicalRecord)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		conflicts, errorResponse := checkPrescriptionAllergies(db, patient.ID, medicalRecord.PrescriptionItems, &medicalRecord.AllergyOverride)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+		if len(conflicts) > 0 {
+			return allergyConflictResponse(c, conflicts)
+		}
+
+		// Interaksi obat tidak memblokir penyimpanan, peringatannya dikembalikan bersama rekam medis
+		interactions, err := helper.FindDrugInteractions(db, medicalRecord.PrescriptionItems)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to check drug interactions",
+			})
+		}
+
 		medicalRecord.DoctorID = doctor.ID
+		medicalRecord.OrganizationID = claims.OrganizationID
 
-		if err := db.Create(&medicalRecord).Error; err != nil {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if patient.ID == 0 {
+				if err := tx.Create(patient).Error; err != nil {
+					return err
+				}
+			}
+			medicalRecord.PatientID = patient.ID
+			if err := tx.Create(&medicalRecord).Error; err != nil {
+				return err
+			}
+			if appointment != nil {
+				if err := completeAppointment(tx, appointment, medicalRecord.ID); err != nil {
+					return err
+				}
+			}
+			// Notifikasi ke pasien hanya diantrekan jika rekam medis berhasil disimpan, lewat email, SMS atau WhatsApp
+			// sesuai kanal pilihan pasien. Pengirimannya dilakukan worker outbox.
+			_, err := helper.EnqueuePatientNotification(tx, models.EmailKindMedicalRecord, patient, helper.EmailPayload{
+				MedicalRecordID: medicalRecord.ID,
+			}, time.Now())
+			return err
+		})
+		if err != nil {
 			errorResponse := helper.ErrorResponse{
 				Code:    http.StatusInternalServerError,
 				Message: "Failed to create medical record",
@@ -31,14 +100,130 @@ func AddMedicalRecord(db *gorm.DB) echo.HandlerFunc {
 			"error":   false,
 			"message": "Medical record created successfully",
 			"data":    medicalRecord,
+			"warnings": map[string]interface{}{
+				"interactions": interactions,
+			},
 		}
 		return c.JSON(http.StatusCreated, successResponse)
 	}
 }
 
+/*
+resolveRecordAppointment memeriksa appointment_id pada rekam medis baru. Janji temu harus masih terjadwal dan milik
+dokter yang membuat rekam medis. Jika patient_id kosong, pasien diambil dari janji temu tersebut.
+*/
+func resolveRecordAppointment(db *gorm.DB, doctor *models.Doctor, claims *auth.Claims, medicalRecord *models.MedicalRecords) (*models.Appointment, *helper.ErrorResponse) {
+	if medicalRecord.AppointmentID == nil {
+		return nil, nil
+	}
+
+	var appointment models.Appointment
+	if err := scopeAppointments(db, doctor, claims).Where("id = ? AND doctor_id = ?", *medicalRecord.AppointmentID, doctor.ID).First(&appointment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &helper.ErrorResponse{Code: http.StatusNotFound, Message: "Appointment not found"}
+		}
+		return nil, &helper.ErrorResponse{Code: http.StatusInternalServerError, Message: "Failed to fetch appointment"}
+	}
+	if appointment.Status != models.AppointmentStatusScheduled {
+		return nil, &helper.ErrorResponse{Code: http.StatusConflict, Message: "Appointment is no longer scheduled"}
+	}
+
+	if medicalRecord.PatientID == 0 {
+		medicalRecord.PatientID = appointment.PatientID
+	} else if medicalRecord.PatientID != appointment.PatientID {
+		return nil, &helper.ErrorResponse{Code: http.StatusBadRequest, Message: "Appointment belongs to a different patient"}
+	}
+	return &appointment, nil
+}
+
+// resolveRecordPatient menentukan pasien untuk rekam medis baru. Jika patient_id dikirim, identitas pasien
+// disalin dari data pasien tersebut. Jika tidak, pasien dicari berdasarkan nama, tanggal lahir dan kontak,
+// dan pasien baru (belum disimpan, ID = 0) disiapkan jika belum ada yang cocok.
+func resolveRecordPatient(db *gorm.DB, doctor *models.Doctor, claims *auth.Claims, medicalRecord *models.MedicalRecords) (*models.Patient, *helper.ErrorResponse) {
+	if medicalRecord.PatientID != 0 {
+		var patient models.Patient
+		if err := scopePatients(db, doctor, claims).Where("id = ?", medicalRecord.PatientID).First(&patient).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, &helper.ErrorResponse{Code: http.StatusNotFound, Message: "Patient not found"}
+			}
+			return nil, &helper.ErrorResponse{Code: http.StatusInternalServerError, Message: "Failed to fetch patient"}
+		}
+		medicalRecord.PatientName = patient.Name
+		medicalRecord.BirthDate = patient.BirthDate
+		medicalRecord.Email = patient.Email
+		medicalRecord.PhoneNumber = patient.PhoneNumber
+		return &patient, nil
+	}
+
+	patient, err := helper.FindMatchingPatient(db, claims.OrganizationID, medicalRecord.PatientName, medicalRecord.BirthDate, medicalRecord.Email, medicalRecord.PhoneNumber)
+	if err != nil {
+		return nil, &helper.ErrorResponse{Code: http.StatusInternalServerError, Message: "Failed to fetch patient"}
+	}
+	if patient == nil {
+		patient = &models.Patient{
+			OrganizationID: claims.OrganizationID,
+			Name:           medicalRecord.PatientName,
+			BirthDate:      medicalRecord.BirthDate,
+			Email:          medicalRecord.Email,
+			PhoneNumber:    medicalRecord.PhoneNumber,
+			CreatedByID:    doctor.ID,
+		}
+	}
+	return patient, nil
+}
+
+/*
+checkPrescriptionAllergies mencocokkan baris resep terstruktur dengan alergi pasien. Jika ada yang cocok dan dokter
+belum menuliskan alasan override, daftar konflik dikembalikan dan rekam medis tidak boleh disimpan. Alasan override
+hanya disimpan jika memang ada konflik. Resep teks bebas tidak bisa diperiksa.
+*/
+func checkPrescriptionAllergies(db *gorm.DB, patientID uint, items []models.PrescriptionItem, overrideReason *string) ([]helper.AllergyConflict, *helper.ErrorResponse) {
+	*overrideReason = strings.TrimSpace(*overrideReason)
+
+	conflicts, err := helper.FindAllergyConflicts(db, patientID, items)
+	if err != nil {
+		return nil, &helper.ErrorResponse{Code: http.StatusInternalServerError, Message: "Failed to check patient allergies"}
+	}
+	if len(conflicts) == 0 {
+		*overrideReason = ""
+		return nil, nil
+	}
+
+	if *overrideReason == "" {
+		return conflicts, nil
+	}
+	if len(*overrideReason) < helper.MinAllergyOverrideReasonLength || len(*overrideReason) > 500 {
+		return nil, &helper.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Allergy override reason must be between 10 and 500 characters",
+		}
+	}
+	return nil, nil
+}
+
+func allergyConflictResponse(c echo.Context, conflicts []helper.AllergyConflict) error {
+	return c.JSON(http.StatusConflict, map[string]interface{}{
+		"code":    http.StatusConflict,
+		"error":   true,
+		"message": "Prescription matches a recorded allergy. Review the conflicts or resubmit with allergy_override_reason",
+		"data":    conflicts,
+	})
+}
+
+// scopeMedicalRecords membatasi query rekam medis ke klinik aktif pada token sehingga dokter dalam
+// klinik yang sama berbagi data pasien dan klinik lain tidak bisa melihatnya. Token tanpa klinik
+// hanya melihat rekam medis yang dibuatnya sendiri.
+func scopeMedicalRecords(query *gorm.DB, doctor *models.Doctor, claims *auth.Claims) *gorm.DB {
+	if claims.OrganizationID == 0 {
+		return query.Where("doctor_id = ?", doctor.ID)
+	}
+	return query.Where("organization_id = ?", claims.OrganizationID)
+}
+
 func GetMedicalRecordsByDoctor(db *gorm.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
 
 		page, err := strconv.Atoi(c.QueryParam("page"))
 		if err != nil || page < 1 {
@@ -55,7 +240,9 @@ func GetMedicalRecordsByDoctor(db *gorm.DB) echo.HandlerFunc {
 		searching := c.QueryParam("searching")
 
 		var medicalRecords []models.MedicalRecords
-		query := db.Where("doctor_id = ?", doctor.ID).
+		query := scopeMedicalRecords(db, doctor, claims).
+			Preload("SecondaryICD10").
+			Preload("PrescriptionItems").
 			Offset(offset).
 			Limit(limit).
 			Order("id DESC")
@@ -67,6 +254,17 @@ func GetMedicalRecordsByDoctor(db *gorm.DB) echo.HandlerFunc {
 					Or("email ILIKE ?", searchPattern))
 		}
 
+		patientID := c.QueryParam("patient_id")
+		if patientID != "" {
+			query = query.Where("patient_id = ?", patientID)
+		}
+
+		// Pencarian berdasarkan NIK mencocokkan NIK pasien di klinik yang sama
+		nik := c.QueryParam("nik")
+		if nik != "" {
+			query = query.Where("patient_id IN (?)", scopePatients(db.Model(&models.Patient{}), doctor, claims).Select("id").Where("nik = ?", nik))
+		}
+
 		if err := query.Find(&medicalRecords).Error; err != nil {
 			errorResponse := helper.ErrorResponse{
 				Code:    http.StatusInternalServerError,
@@ -76,11 +274,17 @@ func GetMedicalRecordsByDoctor(db *gorm.DB) echo.HandlerFunc {
 		}
 
 		var totalRecords int64
-		countQuery := db.Model(&models.MedicalRecords{}).Where("doctor_id = ?", doctor.ID)
+		countQuery := scopeMedicalRecords(db.Model(&models.MedicalRecords{}), doctor, claims)
 		if searching != "" {
 			searchPattern := "%" + searching + "%"
 			countQuery = countQuery.Where("patient_name ILIKE ?", searchPattern)
 		}
+		if patientID != "" {
+			countQuery = countQuery.Where("patient_id = ?", patientID)
+		}
+		if nik != "" {
+			countQuery = countQuery.Where("patient_id IN (?)", scopePatients(db.Model(&models.Patient{}), doctor, claims).Select("id").Where("nik = ?", nik))
+		}
 		countQuery.Count(&totalRecords)
 
 		response := map[string]interface{}{
@@ -99,6 +303,7 @@ func GetMedicalRecordsByDoctor(db *gorm.DB) echo.HandlerFunc {
 func GetMedicalRecordByID(db *gorm.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
 
 		recordID, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
@@ -110,7 +315,7 @@ func GetMedicalRecordByID(db *gorm.DB) echo.HandlerFunc {
 		}
 
 		var medicalRecord models.MedicalRecords
-		if err := db.Where("id = ? AND doctor_id = ?", recordID, doctor.ID).First(&medicalRecord).Error; err != nil {
+		if err := scopeMedicalRecords(db, doctor, claims).Preload("SecondaryICD10").Preload("PrescriptionItems").Where("id = ?", recordID).First(&medicalRecord).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				errorResponse := helper.ErrorResponse{
 					Code:    http.StatusNotFound,
@@ -135,11 +340,12 @@ func GetMedicalRecordByID(db *gorm.DB) echo.HandlerFunc {
 func EditMedicalRecordByID(db *gorm.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
 
 		medicalRecordID := c.Param("id")
 
 		var existingMedicalRecord models.MedicalRecords
-		result := db.First(&existingMedicalRecord, medicalRecordID)
+		result := scopeMedicalRecords(db, doctor, claims).First(&existingMedicalRecord, medicalRecordID)
 		if result.Error != nil {
 			errorResponse := helper.ErrorResponse{
 				Code:    http.StatusNotFound,
@@ -148,7 +354,7 @@ func EditMedicalRecordByID(db *gorm.DB) echo.HandlerFunc {
 			return c.JSON(http.StatusNotFound, errorResponse)
 		}
 
-		if existingMedicalRecord.DoctorID != doctor.ID {
+		if existingMedicalRecord.DoctorID != doctor.ID && !auth.HasPermission(claims.Role, auth.PermissionRecordManageAll) {
 			errorResponse := helper.ErrorResponse{
 				Code:    http.StatusForbidden,
 				Message: "You are not authorized to edit this medical record",
@@ -164,6 +370,37 @@ func EditMedicalRecordByID(db *gorm.DB) echo.HandlerFunc {
 			})
 		}
 
+		// Data identitas pasien dan data klinis punya permission yang berbeda, misalnya perawat
+		// boleh membetulkan nomor telepon pasien tetapi tidak boleh mengubah diagnosis
+		editsDemographics := updatedMedicalRecord.PatientName != "" || updatedMedicalRecord.BirthDate != "" ||
+			updatedMedicalRecord.Email != "" || updatedMedicalRecord.PhoneNumber != ""
+		if editsDemographics && !auth.HasPermission(claims.Role, auth.PermissionRecordEditDemographics) {
+			return c.JSON(http.StatusForbidden, helper.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: "You are not authorized to edit patient information",
+			})
+		}
+		// Identitas pasien yang sudah terdaftar diubah lewat PUT /api/patients/:id supaya melewati validasi yang sama,
+		// misalnya kecocokan NIK dengan tanggal lahir dan nomor telepon untuk kanal SMS/WhatsApp
+		if editsDemographics && existingMedicalRecord.PatientID != 0 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Patient information of this record must be changed through PUT /api/patients/" + strconv.FormatUint(uint64(existingMedicalRecord.PatientID), 10),
+			})
+		}
+
+		editsDiagnosisCodes := updatedMedicalRecord.PrimaryICD10 != "" || updatedMedicalRecord.SecondaryICD10 != nil
+		editsPrescriptionItems := updatedMedicalRecord.PrescriptionItems != nil
+		editsSOAP := !updatedMedicalRecord.SOAP.IsEmpty()
+		editsClinical := editsSOAP || updatedMedicalRecord.Diagnosis != "" || updatedMedicalRecord.Prescription != "" ||
+			updatedMedicalRecord.CareSuggestion != "" || editsDiagnosisCodes || editsPrescriptionItems
+		if editsClinical && !auth.HasPermission(claims.Role, auth.PermissionRecordEditClinical) {
+			return c.JSON(http.StatusForbidden, helper.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: "You are not authorized to edit diagnosis, prescription or care suggestion",
+			})
+		}
+
 		if updatedMedicalRecord.PatientName != "" {
 			if len(updatedMedicalRecord.PatientName) < 1 || len(updatedMedicalRecord.PatientName) > 100 {
 				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
@@ -210,6 +447,29 @@ func EditMedicalRecordByID(db *gorm.DB) echo.HandlerFunc {
 			existingMedicalRecord.PhoneNumber = updatedMedicalRecord.PhoneNumber
 		}
 
+		// Bagian SOAP yang dikirim menggantikan bagian yang lama, bagian yang kosong tidak diubah
+		if editsSOAP {
+			soap, err := helper.ValidateSOAPNote(updatedMedicalRecord.SOAP, false)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: err.Error(),
+				})
+			}
+			if soap.Subjective != "" {
+				existingMedicalRecord.SOAP.Subjective = soap.Subjective
+			}
+			if soap.Objective != "" {
+				existingMedicalRecord.SOAP.Objective = soap.Objective
+			}
+			if soap.Assessment != "" {
+				existingMedicalRecord.SOAP.Assessment = soap.Assessment
+			}
+			if soap.Plan != "" {
+				existingMedicalRecord.SOAP.Plan = soap.Plan
+			}
+		}
+
 		if updatedMedicalRecord.Diagnosis != "" {
 			if len(updatedMedicalRecord.Diagnosis) < 5 || len(updatedMedicalRecord.Diagnosis) > 3000 {
 				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
@@ -220,6 +480,41 @@ func EditMedicalRecordByID(db *gorm.DB) echo.HandlerFunc {
 			existingMedicalRecord.Diagnosis = updatedMedicalRecord.Diagnosis
 		}
 
+		// Kode ICD-10 sekunder diganti seluruhnya jika secondary_icd10 dikirim. Kode primer lama tetap dipakai
+		// jika hanya kode sekunder yang diubah.
+		if editsDiagnosisCodes {
+			primary := updatedMedicalRecord.PrimaryICD10
+			if primary == "" {
+				primary = existingMedicalRecord.PrimaryICD10
+			}
+			secondary := updatedMedicalRecord.SecondaryICD10
+			if secondary == nil {
+				if err := db.Where("medical_record_id = ?", existingMedicalRecord.ID).Find(&secondary).Error; err != nil {
+					return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+						Code:    http.StatusInternalServerError,
+						Message: "Failed to fetch secondary ICD-10 codes",
+					})
+				}
+			}
+
+			primary, secondary, err := helper.ValidateRecordDiagnoses(db, primary, secondary)
+			if err != nil {
+				var invalidDiagnosis *helper.InvalidDiagnosisError
+				if errors.As(err, &invalidDiagnosis) {
+					return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+						Code:    http.StatusBadRequest,
+						Message: err.Error(),
+					})
+				}
+				return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+					Code:    http.StatusInternalServerError,
+					Message: "Failed to validate ICD-10 codes",
+				})
+			}
+			existingMedicalRecord.PrimaryICD10 = primary
+			existingMedicalRecord.SecondaryICD10 = secondary
+		}
+
 		if updatedMedicalRecord.Prescription != "" {
 			if len(updatedMedicalRecord.Prescription) < 5 || len(updatedMedicalRecord.Prescription) > 3000 {
 				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
@@ -230,6 +525,42 @@ func EditMedicalRecordByID(db *gorm.DB) echo.HandlerFunc {
 			existingMedicalRecord.Prescription = updatedMedicalRecord.Prescription
 		}
 
+		// Interaksi obat hanya diperiksa ulang jika daftar obat ikut diubah
+		interactions := []helper.InteractionWarning{}
+
+		// Daftar obat diganti seluruhnya jika prescription_items dikirim
+		if editsPrescriptionItems {
+			prescriptionItems, err := helper.ValidatePrescriptionItems(updatedMedicalRecord.PrescriptionItems)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: err.Error(),
+				})
+			}
+			existingMedicalRecord.PrescriptionItems = prescriptionItems
+			if updatedMedicalRecord.Prescription == "" && len(prescriptionItems) > 0 {
+				existingMedicalRecord.Prescription = helper.SummarizePrescription(prescriptionItems)
+			}
+
+			existingMedicalRecord.AllergyOverride = updatedMedicalRecord.AllergyOverride
+			conflicts, errorResponse := checkPrescriptionAllergies(db, existingMedicalRecord.PatientID, prescriptionItems, &existingMedicalRecord.AllergyOverride)
+			if errorResponse != nil {
+				return c.JSON(errorResponse.Code, errorResponse)
+			}
+			if len(conflicts) > 0 {
+				return allergyConflictResponse(c, conflicts)
+			}
+
+			found, err := helper.FindDrugInteractions(db, prescriptionItems)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+					Code:    http.StatusInternalServerError,
+					Message: "Failed to check drug interactions",
+				})
+			}
+			interactions = found
+		}
+
 		if updatedMedicalRecord.CareSuggestion != "" {
 			if len(updatedMedicalRecord.CareSuggestion) < 5 || len(updatedMedicalRecord.CareSuggestion) > 3000 {
 				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
@@ -240,23 +571,69 @@ func EditMedicalRecordByID(db *gorm.DB) echo.HandlerFunc {
 			existingMedicalRecord.CareSuggestion = updatedMedicalRecord.CareSuggestion
 		}
 
-		db.Save(&existingMedicalRecord)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Omit("SecondaryICD10", "PrescriptionItems").Save(&existingMedicalRecord).Error; err != nil {
+				return err
+			}
+			if editsPrescriptionItems {
+				if err := tx.Where("medical_record_id = ?", existingMedicalRecord.ID).Delete(&models.PrescriptionItem{}).Error; err != nil {
+					return err
+				}
+				for i := range existingMedicalRecord.PrescriptionItems {
+					existingMedicalRecord.PrescriptionItems[i].MedicalRecordID = existingMedicalRecord.ID
+				}
+				if len(existingMedicalRecord.PrescriptionItems) > 0 {
+					if err := tx.Create(&existingMedicalRecord.PrescriptionItems).Error; err != nil {
+						return err
+					}
+				}
+			}
+			if editsDiagnosisCodes {
+				if err := tx.Where("medical_record_id = ?", existingMedicalRecord.ID).Delete(&models.MedicalRecordDiagnosis{}).Error; err != nil {
+					return err
+				}
+				for i := range existingMedicalRecord.SecondaryICD10 {
+					existingMedicalRecord.SecondaryICD10[i].MedicalRecordID = existingMedicalRecord.ID
+				}
+				if len(existingMedicalRecord.SecondaryICD10) > 0 {
+					if err := tx.Create(&existingMedicalRecord.SecondaryICD10).Error; err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to update medical record",
+			})
+		}
 
 		medicalRecordResponse := map[string]interface{}{
 			"code":    http.StatusOK,
 			"error":   false,
 			"message": "Medical record updated successfully",
 			"data": map[string]interface{}{
-				"id":              existingMedicalRecord.ID,
-				"patient_name":    existingMedicalRecord.PatientName,
-				"birth_date":      existingMedicalRecord.BirthDate,
-				"email":           existingMedicalRecord.Email,
-				"phone_number":    existingMedicalRecord.PhoneNumber,
-				"diagnosis":       existingMedicalRecord.Diagnosis,
-				"prescription":    existingMedicalRecord.Prescription,
-				"care_suggestion": existingMedicalRecord.CareSuggestion,
-				"created_at":      existingMedicalRecord.CreatedAt,
-				"updated_at":      existingMedicalRecord.UpdatedAt,
+				"id":                      existingMedicalRecord.ID,
+				"patient_id":              existingMedicalRecord.PatientID,
+				"patient_name":            existingMedicalRecord.PatientName,
+				"birth_date":              existingMedicalRecord.BirthDate,
+				"email":                   existingMedicalRecord.Email,
+				"phone_number":            existingMedicalRecord.PhoneNumber,
+				"soap":                    existingMedicalRecord.SOAP,
+				"diagnosis":               existingMedicalRecord.Diagnosis,
+				"primary_icd10":           existingMedicalRecord.PrimaryICD10,
+				"secondary_icd10":         existingMedicalRecord.SecondaryICD10,
+				"prescription":            existingMedicalRecord.Prescription,
+				"prescription_items":      existingMedicalRecord.PrescriptionItems,
+				"allergy_override_reason": existingMedicalRecord.AllergyOverride,
+				"care_suggestion":         existingMedicalRecord.CareSuggestion,
+				"created_at":              existingMedicalRecord.CreatedAt,
+				"updated_at":              existingMedicalRecord.UpdatedAt,
+			},
+			"warnings": map[string]interface{}{
+				"interactions": interactions,
 			},
 		}
 
@@ -267,11 +644,12 @@ func EditMedicalRecordByID(db *gorm.DB) echo.HandlerFunc {
 func DeleteMedicalRecordByID(db *gorm.DB) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
 
 		medicalRecordID := c.Param("id")
 
 		var existingMedicalRecord models.MedicalRecords
-		result := db.First(&existingMedicalRecord, medicalRecordID)
+		result := scopeMedicalRecords(db, doctor, claims).First(&existingMedicalRecord, medicalRecordID)
 		if result.Error != nil {
 			errorResponse := helper.ErrorResponse{
 				Code:    http.StatusNotFound,
@@ -280,7 +658,7 @@ func DeleteMedicalRecordByID(db *gorm.DB) echo.HandlerFunc {
 			return c.JSON(http.StatusNotFound, errorResponse)
 		}
 
-		if existingMedicalRecord.DoctorID != doctor.ID {
+		if existingMedicalRecord.DoctorID != doctor.ID && !auth.HasPermission(claims.Role, auth.PermissionRecordManageAll) {
 			errorResponse := helper.ErrorResponse{
 				Code:    http.StatusForbidden,
 				Message: "You are not authorized to delete this medical record",
@@ -288,7 +666,19 @@ func DeleteMedicalRecordByID(db *gorm.DB) echo.HandlerFunc {
 			return c.JSON(http.StatusForbidden, errorResponse)
 		}
 
-		if err := db.Delete(&existingMedicalRecord).Error; err != nil {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("medical_record_id = ?", existingMedicalRecord.ID).Delete(&models.MedicalRecordDiagnosis{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("medical_record_id = ?", existingMedicalRecord.ID).Delete(&models.PrescriptionItem{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("medical_record_id = ?", existingMedicalRecord.ID).Delete(&models.VitalSign{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&existingMedicalRecord).Error
+		})
+		if err != nil {
 			errorResponse := helper.ErrorResponse{
 				Code:    http.StatusInternalServerError,
 				Message: "Failed to delete medical record",
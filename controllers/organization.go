@@ -0,0 +1,431 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"medis/auth"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func CreateOrganization(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+
+		var request helper.OrganizationRequest
+		if err := c.Bind(&request); err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body",
+			})
+		}
+
+		request.Name = strings.TrimSpace(request.Name)
+		if len(request.Name) < 3 || len(request.Name) > 100 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Organization name must be between 3 and 100 characters long",
+			})
+		}
+		if request.PhoneNumber != "" && (len(request.PhoneNumber) > 13 || !helper.ValidatePhoneNumber(request.PhoneNumber)) {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "PhoneNumber must contain only digits and be at most 13 characters long",
+			})
+		}
+
+		organization := models.Organization{
+			Name:        request.Name,
+			Address:     request.Address,
+			PhoneNumber: request.PhoneNumber,
+			CreatedByID: doctor.ID,
+		}
+
+		// Pembuat klinik otomatis menjadi owner
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&organization).Error; err != nil {
+				return err
+			}
+			return tx.Create(&models.Membership{
+				OrganizationID: organization.ID,
+				DoctorID:       doctor.ID,
+				Role:           models.MembershipRoleOwner,
+			}).Error
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to create organization",
+			})
+		}
+
+		return c.JSON(http.StatusCreated, map[string]interface{}{
+			"code":    http.StatusCreated,
+			"error":   false,
+			"message": "Organization created successfully",
+			"data":    organization,
+		})
+	}
+}
+
+func ListMyOrganizations(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		var memberships []models.Membership
+		if err := db.Preload("Organization").Where("doctor_id = ?", doctor.ID).Order("id ASC").Find(&memberships).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch organizations",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":                   http.StatusOK,
+			"error":                  false,
+			"message":                "Organizations fetched successfully",
+			"data":                   memberships,
+			"active_organization_id": claims.OrganizationID,
+		})
+	}
+}
+
+func ListOrganizationMembers(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+
+		organizationID, errorResponse := organizationMembership(c, db, doctor.ID, false)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		var memberships []models.Membership
+		if err := db.Where("organization_id = ?", organizationID).Order("id ASC").Find(&memberships).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch organization members",
+			})
+		}
+
+		doctorIDs := make([]uint, 0, len(memberships))
+		for _, membership := range memberships {
+			doctorIDs = append(doctorIDs, membership.DoctorID)
+		}
+		var doctors []models.Doctor
+		db.Where("id IN ?", doctorIDs).Find(&doctors)
+		doctorsByID := map[uint]models.Doctor{}
+		for _, member := range doctors {
+			doctorsByID[member.ID] = member
+		}
+
+		members := make([]map[string]interface{}, 0, len(memberships))
+		for _, membership := range memberships {
+			member := doctorsByID[membership.DoctorID]
+			members = append(members, map[string]interface{}{
+				"doctor_id":       membership.DoctorID,
+				"username":        member.Username,
+				"fullname":        member.Fullname,
+				"role":            member.Role,
+				"membership_role": membership.Role,
+				"joined_at":       membership.CreatedAt,
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Organization members fetched successfully",
+			"data":    members,
+		})
+	}
+}
+
+// InviteOrganizationMember mengundang dokter ke klinik. Dokter baru menjadi anggota setelah menerima undangan
+// lewat AcceptOrganizationInvitation, sehingga owner tidak bisa memasukkan akun orang lain tanpa persetujuannya.
+func InviteOrganizationMember(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+
+		organizationID, errorResponse := organizationMembership(c, db, doctor.ID, true)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		var request helper.AddMemberRequest
+		if err := c.Bind(&request); err != nil || request.Username == "" {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Username is required",
+			})
+		}
+		if request.Role == "" {
+			request.Role = models.MembershipRoleMember
+		}
+		if request.Role != models.MembershipRoleOwner && request.Role != models.MembershipRoleMember {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Role must be either owner or member",
+			})
+		}
+
+		var member models.Doctor
+		if err := db.Where("username = ?", request.Username).First(&member).Error; err != nil {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: "User not found",
+			})
+		}
+
+		if _, err := helper.GetMembership(db, organizationID, member.ID); err == nil {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: "User is already a member of this organization",
+			})
+		}
+
+		var existingInvitation models.OrganizationInvitation
+		if err := db.Where("organization_id = ? AND doctor_id = ?", organizationID, member.ID).First(&existingInvitation).Error; err == nil {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: "User has already been invited to this organization",
+			})
+		}
+
+		invitation := models.OrganizationInvitation{
+			OrganizationID: organizationID,
+			DoctorID:       member.ID,
+			Role:           request.Role,
+			InvitedByID:    doctor.ID,
+		}
+		if err := db.Create(&invitation).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to invite organization member",
+			})
+		}
+
+		return c.JSON(http.StatusCreated, map[string]interface{}{
+			"code":    http.StatusCreated,
+			"error":   false,
+			"message": "Invitation sent, the user joins after accepting it",
+			"data":    invitation,
+		})
+	}
+}
+
+func ListMyInvitations(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+
+		var invitations []models.OrganizationInvitation
+		if err := db.Preload("Organization").Where("doctor_id = ?", doctor.ID).Order("id ASC").Find(&invitations).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch invitations",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Invitations fetched successfully",
+			"data":    invitations,
+		})
+	}
+}
+
+// AcceptOrganizationInvitation menjadikan dokter anggota klinik sesuai role di undangan lalu menghapus undangannya
+func AcceptOrganizationInvitation(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+
+		invitation, errorResponse := findMyInvitation(c, db, doctor.ID)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		membership := models.Membership{
+			OrganizationID: invitation.OrganizationID,
+			DoctorID:       doctor.ID,
+			Role:           invitation.Role,
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if _, err := helper.GetMembership(tx, invitation.OrganizationID, doctor.ID); err == nil {
+				return tx.Delete(invitation).Error
+			} else if !errors.Is(err, helper.ErrNotOrganizationMember) {
+				return err
+			}
+			if err := tx.Create(&membership).Error; err != nil {
+				return err
+			}
+			return tx.Delete(invitation).Error
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to accept invitation",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Invitation accepted, switch to the organization to start working in it",
+			"data":    membership,
+		})
+	}
+}
+
+func DeclineOrganizationInvitation(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+
+		invitation, errorResponse := findMyInvitation(c, db, doctor.ID)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		if err := db.Delete(invitation).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to decline invitation",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Invitation declined",
+		})
+	}
+}
+
+// findMyInvitation membaca :id dari path dan memastikan undangan tersebut ditujukan untuk dokter yang sedang login
+func findMyInvitation(c echo.Context, db *gorm.DB, doctorID uint) (*models.OrganizationInvitation, *helper.ErrorResponse) {
+	invitationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, &helper.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid invitation ID"}
+	}
+
+	var invitation models.OrganizationInvitation
+	if err := db.Where("id = ? AND doctor_id = ?", invitationID, doctorID).First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &helper.ErrorResponse{Code: http.StatusNotFound, Message: "Invitation not found"}
+		}
+		return nil, &helper.ErrorResponse{Code: http.StatusInternalServerError, Message: "Failed to fetch invitation"}
+	}
+	return &invitation, nil
+}
+
+func RemoveOrganizationMember(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+
+		organizationID, errorResponse := organizationMembership(c, db, doctor.ID, true)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		memberID, err := strconv.Atoi(c.Param("doctorId"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid doctor ID",
+			})
+		}
+
+		// Owner tidak bisa mengeluarkan dirinya sendiri supaya klinik tidak kehilangan pengelola
+		if uint(memberID) == doctor.ID {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "You cannot remove yourself from the organization",
+			})
+		}
+
+		membership, err := helper.GetMembership(db, organizationID, uint(memberID))
+		if err != nil {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: "Member not found",
+			})
+		}
+
+		// Sesi yang masih aktif di klinik ini otomatis ditolak oleh VerifyDoctorToken setelah keanggotaan dihapus
+		if err := db.Delete(membership).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to remove organization member",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Member removed successfully",
+		})
+	}
+}
+
+// SwitchOrganization mencabut sesi saat ini dan menerbitkan token baru untuk klinik lain tempat dokter bergabung
+func SwitchOrganization(db *gorm.DB, keys *auth.KeySet) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		organizationID, errorResponse := organizationMembership(c, db, doctor.ID, false)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		if err := helper.RevokeSession(db, claims.SessionID); err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to switch organization",
+			})
+		}
+
+		tokens, err := helper.IssueDoctorSession(db, doctor, organizationID, keys)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to generate token",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":            http.StatusOK,
+			"error":           false,
+			"message":         "Organization switched successfully",
+			"token":           tokens.AccessToken,
+			"refresh_token":   tokens.RefreshToken,
+			"expires_in":      tokens.ExpiresIn,
+			"organization_id": organizationID,
+		})
+	}
+}
+
+// organizationMembership membaca :id dari path dan memastikan dokter adalah anggota (atau owner) klinik tersebut
+func organizationMembership(c echo.Context, db *gorm.DB, doctorID uint, ownerOnly bool) (uint, *helper.ErrorResponse) {
+	organizationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, &helper.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid organization ID"}
+	}
+
+	membership, err := helper.GetMembership(db, uint(organizationID), doctorID)
+	if err != nil {
+		if errors.Is(err, helper.ErrNotOrganizationMember) {
+			return 0, &helper.ErrorResponse{Code: http.StatusForbidden, Message: err.Error()}
+		}
+		return 0, &helper.ErrorResponse{Code: http.StatusInternalServerError, Message: "Failed to check organization membership"}
+	}
+
+	if ownerOnly && membership.Role != models.MembershipRoleOwner {
+		return 0, &helper.ErrorResponse{Code: http.StatusForbidden, Message: "Only organization owners can manage members"}
+	}
+	return uint(organizationID), nil
+}
@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+GetOutboxStatus menampilkan jumlah email per status, umur email pending tertua, dan daftar email sesuai filter status
+(default dead) supaya admin bisa melihat email yang gagal terkirim.
+*/
+func GetOutboxStatus(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		page, err := strconv.Atoi(c.QueryParam("page"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+
+		limit, err := strconv.Atoi(c.QueryParam("limit"))
+		if err != nil || limit < 1 {
+			limit = 10
+		}
+
+		status := c.QueryParam("status")
+		if status == "" {
+			status = models.EmailStatusDead
+		}
+
+		var counts []struct {
+			Status string
+			Total  int64
+		}
+		if err := db.Model(&models.OutboxEmail{}).Select("status, COUNT(*) AS total").Group("status").Scan(&counts).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch outbox status",
+			})
+		}
+		summary := map[string]int64{
+			models.EmailStatusPending: 0,
+			models.EmailStatusSent:    0,
+			models.EmailStatusDead:    0,
+		}
+		for _, count := range counts {
+			summary[count.Status] = count.Total
+		}
+
+		var oldestPending *time.Time
+		var oldest models.OutboxEmail
+		result := db.Where("status = ?", models.EmailStatusPending).Order("created_at ASC").Limit(1).Find(&oldest)
+		if result.Error == nil && result.RowsAffected > 0 {
+			oldestPending = &oldest.CreatedAt
+		}
+
+		query := db.Model(&models.OutboxEmail{}).Where("status = ?", status)
+		if kind := c.QueryParam("kind"); kind != "" {
+			query = query.Where("kind = ?", kind)
+		}
+		if channel := c.QueryParam("channel"); channel != "" {
+			query = query.Where("channel = ?", channel)
+		}
+
+		var totalRecords int64
+		query.Count(&totalRecords)
+
+		var emails []models.OutboxEmail
+		if err := query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&emails).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch outbox emails",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Outbox status fetched successfully",
+			"data": map[string]interface{}{
+				"counts":               summary,
+				"oldest_pending_since": oldestPending,
+				"emails":               emails,
+			},
+			"totalRecords": totalRecords,
+			"page":         page,
+			"limit":        limit,
+		})
+	}
+}
+
+/*
+RetryOutboxEmail mengembalikan email dead letter ke antrean dengan jumlah percobaan dari nol lalu langsung mencoba
+mengirimnya lewat notifier, sehingga admin bisa melihat hasilnya tanpa menunggu worker. Jika gagal, email tetap
+diantrekan dan dicoba lagi oleh worker. Link verifikasi dan reset password dibuat ulang saat pengiriman, sehingga
+email yang dikirim ulang tidak berisi token yang sudah kedaluwarsa.
+*/
+func RetryOutboxEmail(db *gorm.DB, notifier helper.Notifier, messenger helper.MessagingProvider) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		emailID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid email ID",
+			})
+		}
+
+		result := db.Model(&models.OutboxEmail{}).
+			Where("id = ? AND status = ?", emailID, models.EmailStatusDead).
+			Updates(map[string]interface{}{
+				"status":          models.EmailStatusPending,
+				"attempts":        0,
+				"next_attempt_at": time.Now(),
+			})
+		if result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to requeue email",
+			})
+		}
+		if result.RowsAffected == 0 {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: "Dead-lettered email not found",
+			})
+		}
+
+		email, err := helper.SendOutboxEmail(db, notifier, messenger, uint(emailID), helper.OutboxMaxAttempts(), time.Now())
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Email requeued but failed to send it",
+			})
+		}
+
+		message := "Email sent successfully"
+		if email.Status != models.EmailStatusSent {
+			message = "Email requeued successfully"
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": message,
+			"data":    email,
+		})
+	}
+}
+
+/*
+PreviewEmail merender template email dengan data contoh. Query locale memilih bahasa (id atau en) dan query format
+memilih keluaran: json (default) berisi subject, html dan text, sedangkan html atau text mengembalikan isi email
+apa adanya supaya bisa langsung dibuka di browser. Query channel=sms atau whatsapp menampilkan isi pesan teksnya.
+*/
+func PreviewEmail(c echo.Context) error {
+	locale := strings.ToLower(c.QueryParam("locale"))
+	if locale == "" {
+		locale = helper.DefaultLocale
+	}
+	if !helper.IsSupportedLocale(locale) {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Locale must be either id or en",
+		})
+	}
+
+	kind := c.Param("kind")
+	if channel := c.QueryParam("channel"); helper.IsMessagingChannel(channel) {
+		return previewTextMessage(c, kind, locale, channel)
+	}
+
+	message, err := helper.RenderEmail(kind, locale, helper.SampleEmailData(time.Now()))
+	if errors.Is(err, helper.ErrUnknownEmailKind) {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+			Code:    http.StatusNotFound,
+			Message: "Email template not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to render email template",
+		})
+	}
+
+	switch c.QueryParam("format") {
+	case "html":
+		return c.HTML(http.StatusOK, message.HTMLBody)
+	case "text":
+		return c.String(http.StatusOK, message.TextBody)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"code":    http.StatusOK,
+		"error":   false,
+		"message": "Email preview rendered successfully",
+		"data": map[string]interface{}{
+			"kind":    kind,
+			"locale":  locale,
+			"subject": message.Subject,
+			"html":    message.HTMLBody,
+			"text":    message.TextBody,
+		},
+	})
+}
+
+func previewTextMessage(c echo.Context, kind, locale, channel string) error {
+	body, err := helper.RenderTextMessage(kind, locale, helper.SampleEmailData(time.Now()))
+	if errors.Is(err, helper.ErrUnknownMessageKind) {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+			Code:    http.StatusNotFound,
+			Message: "Message template not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to render message template",
+		})
+	}
+
+	if c.QueryParam("format") == "text" {
+		return c.String(http.StatusOK, body)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"code":    http.StatusOK,
+		"error":   false,
+		"message": "Message preview rendered successfully",
+		"data": map[string]interface{}{
+			"kind":    kind,
+			"locale":  locale,
+			"channel": channel,
+			"text":    body,
+		},
+	})
+}
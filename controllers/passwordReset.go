@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"html/template"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func ForgotPassword(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		request := c.Get("forgotPassword").(helper.ForgotPasswordRequest)
+
+		// Response selalu sama agar endpoint ini tidak bisa dipakai untuk mengecek email yang terdaftar
+		successResponse := map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "If the email is registered, a password reset link has been sent",
+		}
+
+		var doctor models.Doctor
+		if err := db.Where("email = ?", request.Email).First(&doctor).Error; err != nil {
+			return c.JSON(http.StatusOK, successResponse)
+		}
+
+		// Setiap email reset membatalkan link sebelumnya, sehingga permintaan dibatasi seperti kirim ulang verifikasi.
+		// Isi response tetap sama dengan response sukses.
+		if doctor.PasswordResetSentAt != nil {
+			retryAt := doctor.PasswordResetSentAt.Add(helper.PasswordResetCooldown)
+			if wait := time.Until(retryAt); wait > 0 {
+				successResponse["code"] = http.StatusTooManyRequests
+				c.Response().Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+				return c.JSON(http.StatusTooManyRequests, successResponse)
+			}
+		}
+
+		// Token reset dibuat worker outbox saat email dikirim sehingga tidak tersimpan di payload outbox
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&doctor).Update("password_reset_sent_at", time.Now()).Error; err != nil {
+				return err
+			}
+			return helper.EnqueueEmail(tx, models.EmailKindPasswordReset, doctor.Email, helper.EmailPayload{
+				Name:     doctor.FirstName + " " + doctor.LastName,
+				DoctorID: doctor.ID,
+				Locale:   doctor.Language,
+			})
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to create password reset token",
+			})
+		}
+
+		return c.JSON(http.StatusOK, successResponse)
+	}
+}
+
+func ResetPassword(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		request := c.Get("resetPassword").(helper.ResetPasswordRequest)
+		tokenHash := helper.HashToken(request.Token)
+
+		var doctor models.Doctor
+		result := db.Where("password_reset_token_hash = ? AND password_reset_expires_at > ?", tokenHash, time.Now()).First(&doctor)
+		if result.Error != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid or expired reset token",
+			})
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.NewPassword), bcrypt.DefaultCost)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to hash password",
+			})
+		}
+
+		// Token dihapus dalam update yang sama dan bersyarat pada hash token, sehingga token hanya bisa dipakai sekali
+		update := db.Model(&models.Doctor{}).
+			Where("id = ? AND password_reset_token_hash = ?", doctor.ID, tokenHash).
+			Updates(map[string]interface{}{
+				"password":                  string(hashedPassword),
+				"password_reset_token_hash": "",
+				"password_reset_expires_at": nil,
+			})
+		if update.Error != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to reset password",
+			})
+		}
+		if update.RowsAffected != 1 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid or expired reset token",
+			})
+		}
+
+		// Semua sesi lama dicabut supaya siapa pun yang memegang token lama harus login ulang
+		if err := helper.RevokeAllSessions(db, doctor.ID); err != nil {
+			fmt.Println("Failed to revoke sessions after password reset:", err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Password has been reset successfully",
+		})
+	}
+}
+
+func ResetPasswordPage(c echo.Context) error {
+	tmpl, err := template.ParseFiles("helper/resetPassword.html")
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	err = tmpl.Execute(c.Response().Writer, map[string]string{
+		"Token": c.QueryParam("token"),
+	})
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return nil
+}
@@ -0,0 +1,359 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"medis/auth"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+	"strconv"
+)
+
+// scopePatients membatasi query pasien ke klinik aktif pada token, sama seperti scopeMedicalRecords
+func scopePatients(query *gorm.DB, doctor *models.Doctor, claims *auth.Claims) *gorm.DB {
+	if claims.OrganizationID == 0 {
+		return query.Where("created_by_id = ?", doctor.ID)
+	}
+	return query.Where("organization_id = ?", claims.OrganizationID)
+}
+
+func CreatePatient(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+		request := c.Get("patient").(helper.PatientRequest)
+
+		existingPatient, err := helper.FindMatchingPatient(db, claims.OrganizationID, request.Name, request.BirthDate, request.Email, request.PhoneNumber)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to create patient",
+			})
+		}
+		if existingPatient != nil {
+			return c.JSON(http.StatusConflict, map[string]interface{}{
+				"code":    http.StatusConflict,
+				"message": "Patient already registered",
+				"data":    existingPatient,
+			})
+		}
+
+		patient := models.Patient{
+			OrganizationID:   claims.OrganizationID,
+			NIK:              request.NIK,
+			BPJSNumber:       request.BPJSNumber,
+			Name:             request.Name,
+			Gender:           request.Gender,
+			BirthDate:        request.BirthDate,
+			Email:            request.Email,
+			PhoneNumber:      request.PhoneNumber,
+			Language:         helper.NormalizeLocale(request.Language),
+			PreferredChannel: request.PreferredChannel,
+			CreatedByID:      doctor.ID,
+		}
+		if errorResponse := checkPatientIdentifiers(db, &patient); errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+		if err := db.Create(&patient).Error; err != nil {
+			if errorResponse := helper.PatientIdentifierConflict(err); errorResponse != nil {
+				return c.JSON(errorResponse.Code, errorResponse)
+			}
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to create patient",
+			})
+		}
+
+		return c.JSON(http.StatusCreated, map[string]interface{}{
+			"code":    http.StatusCreated,
+			"error":   false,
+			"message": "Patient created successfully",
+			"data":    patient,
+		})
+	}
+}
+
+func GetPatients(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		page, err := strconv.Atoi(c.QueryParam("page"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+
+		limit, err := strconv.Atoi(c.QueryParam("limit"))
+		if err != nil || limit < 1 {
+			limit = 10
+		}
+
+		query := scopePatients(db.Model(&models.Patient{}), doctor, claims)
+		if searching := c.QueryParam("searching"); searching != "" {
+			searchPattern := "%" + searching + "%"
+			query = query.Where(
+				db.Where("name ILIKE ?", searchPattern).
+					Or("email ILIKE ?", searchPattern).
+					Or("phone_number LIKE ?", searchPattern).
+					Or("nik LIKE ?", searching+"%").
+					Or("bpjs_number LIKE ?", searching+"%"))
+		}
+		if nik := c.QueryParam("nik"); nik != "" {
+			query = query.Where("nik = ?", nik)
+		}
+		if bpjsNumber := c.QueryParam("bpjs_number"); bpjsNumber != "" {
+			query = query.Where("bpjs_number = ?", bpjsNumber)
+		}
+
+		var totalRecords int64
+		query.Count(&totalRecords)
+
+		var patients []models.Patient
+		if err := query.Order("name ASC").Offset((page - 1) * limit).Limit(limit).Find(&patients).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch patients",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":         http.StatusOK,
+			"error":        false,
+			"message":      "Patients fetched successfully",
+			"data":         patients,
+			"totalRecords": totalRecords,
+			"page":         page,
+			"limit":        limit,
+		})
+	}
+}
+
+// GetPatientByID mengembalikan data pasien beserta seluruh riwayat kunjungannya, dari yang terbaru
+func GetPatientByID(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		patient, errorResponse := findPatient(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		visits := []models.MedicalRecords{}
+		if auth.HasPermission(claims.Role, auth.PermissionRecordRead) {
+			if err := scopeMedicalRecords(db, doctor, claims).Preload("SecondaryICD10").Preload("PrescriptionItems").Where("patient_id = ?", patient.ID).Order("id DESC").Find(&visits).Error; err != nil {
+				return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+					Code:    http.StatusInternalServerError,
+					Message: "Failed to fetch visit history",
+				})
+			}
+		}
+
+		var allergies []models.PatientAllergy
+		db.Where("patient_id = ?", patient.ID).Order("id ASC").Find(&allergies)
+
+		problems := []models.PatientProblem{}
+		if auth.HasPermission(claims.Role, auth.PermissionRecordRead) {
+			db.Where("patient_id = ? AND status = ?", patient.ID, models.ProblemStatusActive).Order("id ASC").Find(&problems)
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Patient fetched successfully",
+			"data": map[string]interface{}{
+				"patient":   patient,
+				"allergies": allergies,
+				"problems":  problems,
+				"visits":    visits,
+			},
+		})
+	}
+}
+
+func UpdatePatient(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+		request := c.Get("patient").(helper.PatientRequest)
+
+		patient, errorResponse := findPatient(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		if request.NIK != "" {
+			patient.NIK = request.NIK
+		}
+		if request.BPJSNumber != "" {
+			patient.BPJSNumber = request.BPJSNumber
+		}
+		if request.Name != "" {
+			patient.Name = request.Name
+		}
+		if request.Gender != "" {
+			patient.Gender = request.Gender
+		}
+		if request.BirthDate != "" {
+			patient.BirthDate = request.BirthDate
+		}
+		if request.Email != "" {
+			patient.Email = request.Email
+		}
+		if request.PhoneNumber != "" {
+			patient.PhoneNumber = request.PhoneNumber
+		}
+		if request.Language != "" {
+			patient.Language = request.Language
+		}
+		if request.PreferredChannel != "" {
+			patient.PreferredChannel = request.PreferredChannel
+		}
+		if helper.IsMessagingChannel(patient.PreferredChannel) && (request.PreferredChannel != "" || request.PhoneNumber != "") {
+			if _, err := helper.NormalizePhoneNumber(patient.PhoneNumber); err != nil {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Phone number cannot be used for SMS or WhatsApp",
+				})
+			}
+		}
+
+		// NIK diperiksa ulang terhadap tanggal lahir dan jenis kelamin hasil gabungan data lama dan baru
+		if patient.NIK != "" && (request.NIK != "" || request.BirthDate != "" || request.Gender != "") {
+			if err := helper.ValidateNIK(patient.NIK, patient.BirthDate, patient.Gender); err != nil {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: err.Error(),
+				})
+			}
+			if patient.Gender == "" {
+				patient.Gender = helper.GenderFromNIK(patient.NIK)
+			}
+		}
+		if errorResponse := checkPatientIdentifiers(db, patient); errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(patient).Error; err != nil {
+				return err
+			}
+			return helper.SyncPatientRecords(tx, patient)
+		})
+		if errorResponse := helper.PatientIdentifierConflict(err); errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to update patient",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Patient updated successfully",
+			"data":    patient,
+		})
+	}
+}
+
+func DeletePatient(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		patient, errorResponse := findPatient(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		// Pasien yang sudah punya rekam medis tidak boleh dihapus agar riwayat kunjungan tidak kehilangan pemiliknya
+		var recordCount int64
+		db.Model(&models.MedicalRecords{}).Where("patient_id = ?", patient.ID).Count(&recordCount)
+		if recordCount > 0 {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: "Patient still has medical records and cannot be deleted",
+			})
+		}
+
+		var appointmentCount int64
+		db.Model(&models.Appointment{}).Where("patient_id = ? AND status = ?", patient.ID, models.AppointmentStatusScheduled).Count(&appointmentCount)
+		if appointmentCount > 0 {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: "Patient still has scheduled appointments and cannot be deleted",
+			})
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("patient_id = ?", patient.ID).Delete(&models.Appointment{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("patient_id = ?", patient.ID).Delete(&models.QueueEntry{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("patient_id = ?", patient.ID).Delete(&models.PatientAllergy{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("patient_id = ?", patient.ID).Delete(&models.PatientProblem{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(patient).Error
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to delete patient",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Patient deleted successfully",
+		})
+	}
+}
+
+// checkPatientIdentifiers memastikan NIK dan nomor BPJS belum dipakai pasien lain di klinik yang sama
+func checkPatientIdentifiers(db *gorm.DB, patient *models.Patient) *helper.ErrorResponse {
+	var count int64
+	if patient.NIK != "" {
+		db.Model(&models.Patient{}).
+			Where("organization_id = ? AND nik = ? AND id <> ?", patient.OrganizationID, patient.NIK, patient.ID).
+			Count(&count)
+		if count > 0 {
+			return &helper.ErrorResponse{Code: http.StatusConflict, Message: helper.PatientNIKConflictMessage}
+		}
+	}
+	if patient.BPJSNumber != "" {
+		db.Model(&models.Patient{}).
+			Where("organization_id = ? AND bpjs_number = ? AND id <> ?", patient.OrganizationID, patient.BPJSNumber, patient.ID).
+			Count(&count)
+		if count > 0 {
+			return &helper.ErrorResponse{Code: http.StatusConflict, Message: helper.PatientBPJSConflictMessage}
+		}
+	}
+	return nil
+}
+
+func findPatient(c echo.Context, db *gorm.DB, doctor *models.Doctor, claims *auth.Claims) (*models.Patient, *helper.ErrorResponse) {
+	patientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, &helper.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid patient ID"}
+	}
+
+	var patient models.Patient
+	if err := scopePatients(db, doctor, claims).Where("id = ?", patientID).First(&patient).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &helper.ErrorResponse{Code: http.StatusNotFound, Message: "Patient not found"}
+		}
+		return nil, &helper.ErrorResponse{Code: http.StatusInternalServerError, Message: "Failed to fetch patient"}
+	}
+	return &patient, nil
+}
@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"medis/auth"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+)
+
+func GetPatientAllergies(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		patient, errorResponse := findPatient(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		var allergies []models.PatientAllergy
+		if err := db.Where("patient_id = ?", patient.ID).Order("id ASC").Find(&allergies).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch allergies",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Allergies fetched successfully",
+			"data":    allergies,
+		})
+	}
+}
+
+func AddPatientAllergy(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+		request := c.Get("allergy").(helper.AllergyRequest)
+
+		patient, errorResponse := findPatient(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		allergy := models.PatientAllergy{
+			PatientID:      patient.ID,
+			OrganizationID: patient.OrganizationID,
+			Substance:      request.Substance,
+			KFACode:        request.KFACode,
+			Reaction:       request.Reaction,
+			Severity:       request.Severity,
+			RecordedByID:   doctor.ID,
+		}
+		if err := db.Create(&allergy).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to save allergy",
+			})
+		}
+
+		return c.JSON(http.StatusCreated, map[string]interface{}{
+			"code":    http.StatusCreated,
+			"error":   false,
+			"message": "Allergy recorded successfully",
+			"data":    allergy,
+		})
+	}
+}
+
+func DeletePatientAllergy(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		patient, errorResponse := findPatient(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		result := db.Where("id = ? AND patient_id = ?", c.Param("allergyId"), patient.ID).Delete(&models.PatientAllergy{})
+		if result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to delete allergy",
+			})
+		}
+		if result.RowsAffected == 0 {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: "Allergy not found",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Allergy deleted successfully",
+		})
+	}
+}
+
+// GetPatientProblems mengembalikan problem list pasien. Query param status menyaring berdasarkan status.
+func GetPatientProblems(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		patient, errorResponse := findPatient(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		query := db.Where("patient_id = ?", patient.ID)
+		if status := c.QueryParam("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+
+		var problems []models.PatientProblem
+		if err := query.Order("id ASC").Find(&problems).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch problem list",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Problem list fetched successfully",
+			"data":    problems,
+		})
+	}
+}
+
+func AddPatientProblem(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+		request := c.Get("problem").(helper.ProblemRequest)
+
+		patient, errorResponse := findPatient(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		problem := models.PatientProblem{
+			PatientID:      patient.ID,
+			OrganizationID: patient.OrganizationID,
+			ICD10Code:      request.ICD10Code,
+			Description:    request.Description,
+			Status:         request.Status,
+			OnsetDate:      request.OnsetDate,
+			ResolvedDate:   request.ResolvedDate,
+			RecordedByID:   doctor.ID,
+		}
+		if err := db.Create(&problem).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to save problem",
+			})
+		}
+
+		return c.JSON(http.StatusCreated, map[string]interface{}{
+			"code":    http.StatusCreated,
+			"error":   false,
+			"message": "Problem added successfully",
+			"data":    problem,
+		})
+	}
+}
+
+func UpdatePatientProblem(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+		request := c.Get("problem").(helper.ProblemRequest)
+
+		patient, errorResponse := findPatient(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		var problem models.PatientProblem
+		if err := db.Where("id = ? AND patient_id = ?", c.Param("problemId"), patient.ID).First(&problem).Error; err != nil {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: "Problem not found",
+			})
+		}
+
+		problem.ICD10Code = request.ICD10Code
+		problem.Description = request.Description
+		problem.Status = request.Status
+		problem.OnsetDate = request.OnsetDate
+		problem.ResolvedDate = request.ResolvedDate
+		if err := db.Save(&problem).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to update problem",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Problem updated successfully",
+			"data":    problem,
+		})
+	}
+}
+
+func DeletePatientProblem(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		patient, errorResponse := findPatient(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		result := db.Where("id = ? AND patient_id = ?", c.Param("problemId"), patient.ID).Delete(&models.PatientProblem{})
+		if result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to delete problem",
+			})
+		}
+		if result.RowsAffected == 0 {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: "Problem not found",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Problem deleted successfully",
+		})
+	}
+}
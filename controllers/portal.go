@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+/*
+GetPortalMedicalRecord melayani link portal pasien yang dikirim bersama ringkasan rekam medis. Pasien tidak punya akun,
+sehingga akses hanya diberikan lewat link bertanda tangan dari helper.MedicalRecordPortalLink yang berlaku sementara.
+*/
+func GetPortalMedicalRecord(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		recordID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid medical record ID",
+			})
+		}
+
+		err = helper.VerifySignedLink(helper.MedicalRecordPortalPath(uint(recordID)), c.QueryParams(), time.Now())
+		if errors.Is(err, helper.ErrExpiredSignedLink) {
+			return c.JSON(http.StatusGone, helper.ErrorResponse{
+				Code:    http.StatusGone,
+				Message: "This link has expired. Please contact your clinic for a new one",
+			})
+		}
+		if err != nil {
+			return c.JSON(http.StatusForbidden, helper.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: "Invalid link",
+			})
+		}
+
+		var medicalRecord models.MedicalRecords
+		err = db.Preload("SecondaryICD10").Preload("PrescriptionItems").First(&medicalRecord, recordID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: "Medical record not found",
+			})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch medical record",
+			})
+		}
+
+		pdfBytes, err := helper.GenerateMedicalRecordPDF(medicalRecord)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to generate medical record PDF",
+			})
+		}
+
+		c.Response().Header().Set("Content-Disposition", `inline; filename="medical_record.pdf"`)
+		c.Response().Header().Set("Cache-Control", "no-store")
+		return c.Blob(http.StatusOK, "application/pdf", pdfBytes)
+	}
+}
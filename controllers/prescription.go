@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"medis/auth"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+)
+
+// CheckPrescription memeriksa interaksi antarobat, dan alergi jika patient_id dikirim, tanpa menyimpan apa pun
+func CheckPrescription(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+		request := c.Get("prescriptionCheck").(helper.PrescriptionCheckRequest)
+
+		interactions, err := helper.FindDrugInteractions(db, request.PrescriptionItems)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to check drug interactions",
+			})
+		}
+
+		allergyConflicts := []helper.AllergyConflict{}
+		if request.PatientID != 0 {
+			var patient models.Patient
+			if err := scopePatients(db, doctor, claims).Where("id = ?", request.PatientID).First(&patient).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+						Code:    http.StatusNotFound,
+						Message: "Patient not found",
+					})
+				}
+				return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+					Code:    http.StatusInternalServerError,
+					Message: "Failed to fetch patient",
+				})
+			}
+
+			conflicts, err := helper.FindAllergyConflicts(db, patient.ID, request.PrescriptionItems)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+					Code:    http.StatusInternalServerError,
+					Message: "Failed to check patient allergies",
+				})
+			}
+			if conflicts != nil {
+				allergyConflicts = conflicts
+			}
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Prescription checked successfully",
+			"data": map[string]interface{}{
+				"interactions":      interactions,
+				"allergy_conflicts": allergyConflicts,
+			},
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"medis/auth"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+	"strings"
+)
+
+// doctorProfile adalah representasi akun yang aman dikirim ke client (tanpa password, token, dan secret 2FA)
+func doctorProfile(doctor models.Doctor) map[string]interface{} {
+	return map[string]interface{}{
+		"id":             doctor.ID,
+		"username":       doctor.Username,
+		"first_name":     doctor.FirstName,
+		"last_name":      doctor.LastName,
+		"fullname":       doctor.Fullname,
+		"email":          doctor.Email,
+		"pending_email":  doctor.PendingEmail,
+		"contact_number": doctor.ContactNumber,
+		"gender":         doctor.Gender,
+		"language":       doctor.Language,
+		"role":           doctor.Role,
+		"is_verified":    doctor.IsVerified,
+		"totp_enabled":   doctor.TOTPEnabled,
+		"license": map[string]interface{}{
+			"str_number":          doctor.STRNumber,
+			"sip_number":          doctor.SIPNumber,
+			"specialty":           doctor.Specialty,
+			"license_expiry_date": doctor.LicenseExpiryDate,
+			"approval_status":     doctor.ApprovalStatus,
+			"approved_at":         doctor.ApprovedAt,
+			"rejection_reason":    doctor.RejectionReason,
+		},
+	}
+}
+
+func GetProfile(c echo.Context) error {
+	doctor := c.Get("doctor").(*models.Doctor)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"code":    http.StatusOK,
+		"error":   false,
+		"message": "Profile fetched successfully",
+		"data":    doctorProfile(*doctor),
+	})
+}
+
+func UpdateProfile(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		request := c.Get("profileUpdate").(helper.UpdateProfileRequest)
+
+		if request.FirstName != "" {
+			doctor.FirstName = request.FirstName
+		}
+		if request.LastName != "" {
+			doctor.LastName = request.LastName
+		}
+		if request.ContactNumber != "" {
+			doctor.ContactNumber = request.ContactNumber
+		}
+		if request.Gender != "" {
+			doctor.Gender = request.Gender
+		}
+		if request.Language != "" {
+			doctor.Language = request.Language
+		}
+		doctor.Fullname = strings.TrimSpace(doctor.FirstName + " " + doctor.LastName)
+
+		if err := db.Model(doctor).Updates(map[string]interface{}{
+			"first_name":     doctor.FirstName,
+			"last_name":      doctor.LastName,
+			"fullname":       doctor.Fullname,
+			"contact_number": doctor.ContactNumber,
+			"gender":         doctor.Gender,
+			"language":       doctor.Language,
+		}).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to update profile",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Profile updated successfully",
+			"data":    doctorProfile(*doctor),
+		})
+	}
+}
+
+func ChangePassword(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+		request := c.Get("changePassword").(helper.ChangePasswordRequest)
+
+		if err := bcrypt.CompareHashAndPassword([]byte(doctor.Password), []byte(request.CurrentPassword)); err != nil {
+			return c.JSON(http.StatusUnauthorized, helper.ErrorResponse{
+				Code:    http.StatusUnauthorized,
+				Message: "Current password is incorrect",
+			})
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.NewPassword), bcrypt.DefaultCost)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to hash password",
+			})
+		}
+
+		if err := db.Model(doctor).Update("password", string(hashedPassword)).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to change password",
+			})
+		}
+
+		// Sesi di perangkat lain dicabut, sesi yang sedang dipakai tetap berjalan
+		if err := helper.RevokeOtherSessions(db, doctor.ID, claims.SessionID); err != nil {
+			fmt.Println("Failed to revoke sessions after password change:", err)
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Password changed successfully",
+		})
+	}
+}
+
+func ChangeEmail(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		request := c.Get("changeEmail").(helper.ChangeEmailRequest)
+
+		if err := bcrypt.CompareHashAndPassword([]byte(doctor.Password), []byte(request.CurrentPassword)); err != nil {
+			return c.JSON(http.StatusUnauthorized, helper.ErrorResponse{
+				Code:    http.StatusUnauthorized,
+				Message: "Current password is incorrect",
+			})
+		}
+
+		if strings.EqualFold(request.Email, doctor.Email) {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "New email must be different from the current email",
+			})
+		}
+
+		var existingDoctor models.Doctor
+		result := db.Where("(email = ? OR pending_email = ?) AND id <> ?", request.Email, request.Email, doctor.ID).First(&existingDoctor)
+		if result.Error == nil {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: "Email already exists",
+			})
+		} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to check email",
+			})
+		}
+
+		// Email lama tetap dipakai sampai alamat baru diverifikasi lewat link /verify
+		doctor.PendingEmail = request.Email
+		helper.NewVerificationToken(doctor)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(doctor).Error; err != nil {
+				return err
+			}
+			return helper.EnqueueEmail(tx, models.EmailKindEmailChange, doctor.PendingEmail, helper.EmailPayload{
+				Name:     doctor.FirstName + " " + doctor.LastName,
+				DoctorID: doctor.ID,
+				Locale:   doctor.Language,
+			})
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to change email",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "A verification link has been sent to the new email address",
+			"data":    doctorProfile(*doctor),
+		})
+	}
+}
@@ -0,0 +1,400 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"medis/auth"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Interval komentar SSE supaya proxy tidak menutup koneksi layar antrian yang sedang diam
+const queueHeartbeatInterval = 25 * time.Second
+
+// scopeQueue membatasi antrian ke klinik aktif, atau ke dokter itu sendiri tanpa klinik
+func scopeQueue(query *gorm.DB, doctor *models.Doctor, claims *auth.Claims) *gorm.DB {
+	if claims.OrganizationID == 0 {
+		return query.Where("doctor_id = ?", doctor.ID)
+	}
+	return query.Where("organization_id = ?", claims.OrganizationID)
+}
+
+// CheckInQueue memberi nomor antrian hari ini kepada pasien yang datang, dengan atau tanpa janji temu
+func CheckInQueue(db *gorm.DB, broker *helper.QueueBroker) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		var request helper.QueueCheckInRequest
+		if err := c.Bind(&request); err != nil || request.DoctorID == 0 || request.PatientID == 0 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Doctor ID and patient ID are required",
+			})
+		}
+
+		if errorResponse := checkAppointmentDoctor(db, doctor, claims, request.DoctorID); errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		var patient models.Patient
+		if err := scopePatients(db, doctor, claims).Where("id = ?", request.PatientID).First(&patient).Error; err != nil {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: "Patient not found",
+			})
+		}
+
+		now := time.Now()
+		queueDate := helper.QueueDate(now)
+
+		if request.AppointmentID != nil {
+			var appointment models.Appointment
+			err := scopeAppointments(db, doctor, claims).
+				Where("id = ? AND doctor_id = ? AND patient_id = ? AND status = ?", *request.AppointmentID, request.DoctorID, patient.ID, models.AppointmentStatusScheduled).
+				First(&appointment).Error
+			if err != nil || helper.QueueDate(appointment.StartsAt) != queueDate {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Appointment is not scheduled today for this doctor and patient",
+				})
+			}
+		}
+
+		// Pasien yang masih menunggu atau sedang dipanggil tidak diberi nomor kedua
+		var activeEntry models.QueueEntry
+		result := db.Where("organization_id = ? AND doctor_id = ? AND queue_date = ? AND patient_id = ? AND status IN ?",
+			claims.OrganizationID, request.DoctorID, queueDate, patient.ID, []string{models.QueueStatusWaiting, models.QueueStatusCalled}).
+			Limit(1).Find(&activeEntry)
+		if result.Error == nil && result.RowsAffected > 0 {
+			return c.JSON(http.StatusConflict, map[string]interface{}{
+				"code":    http.StatusConflict,
+				"message": "Patient is already in today's queue",
+				"data":    activeEntry,
+			})
+		}
+
+		entry := models.QueueEntry{
+			OrganizationID: claims.OrganizationID,
+			DoctorID:       request.DoctorID,
+			QueueDate:      queueDate,
+			PatientID:      patient.ID,
+			AppointmentID:  request.AppointmentID,
+			Status:         models.QueueStatusWaiting,
+			CheckedInByID:  doctor.ID,
+			CheckedInAt:    now,
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			number, err := helper.NextQueueNumber(tx, entry.OrganizationID, entry.DoctorID, entry.QueueDate)
+			if err != nil {
+				return err
+			}
+			entry.Number = number
+			return tx.Create(&entry).Error
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to check in patient",
+			})
+		}
+
+		publishQueue(db, broker, entry.OrganizationID, entry.DoctorID, entry.QueueDate)
+
+		return c.JSON(http.StatusCreated, map[string]interface{}{
+			"code":    http.StatusCreated,
+			"error":   false,
+			"message": fmt.Sprintf("Patient checked in with queue number %d", entry.Number),
+			"data":    entry,
+		})
+	}
+}
+
+// GetQueue mengembalikan seluruh antrian seorang dokter (query param doctor_id, default dokter yang login) pada
+// tanggal tertentu (query param date, default hari ini)
+func GetQueue(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		doctorID := doctor.ID
+		if value := c.QueryParam("doctor_id"); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Invalid doctor ID",
+				})
+			}
+			doctorID = uint(id)
+		}
+
+		queueDate := c.QueryParam("date")
+		if queueDate == "" {
+			queueDate = helper.QueueDate(time.Now())
+		} else if !helper.ValidateDateFormat(queueDate) {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Date must be in the format yyyy-mm-dd",
+			})
+		}
+
+		var entries []models.QueueEntry
+		if err := scopeQueue(db, doctor, claims).Where("doctor_id = ? AND queue_date = ?", doctorID, queueDate).
+			Order("number ASC").Find(&entries).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch queue",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Queue fetched successfully",
+			"data":    entries,
+		})
+	}
+}
+
+// CallNextQueue memanggil nomor menunggu terkecil. Baris dikunci dengan SKIP LOCKED supaya dua panggilan bersamaan
+// tidak memanggil pasien yang sama.
+func CallNextQueue(db *gorm.DB, broker *helper.QueueBroker) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		var request helper.QueueDoctorRequest
+		_ = c.Bind(&request)
+		if request.DoctorID == 0 {
+			request.DoctorID = doctor.ID
+		}
+		if errorResponse := checkAppointmentDoctor(db, doctor, claims, request.DoctorID); errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		queueDate := helper.QueueDate(time.Now())
+		var entry models.QueueEntry
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("organization_id = ? AND doctor_id = ? AND queue_date = ? AND status = ?",
+					claims.OrganizationID, request.DoctorID, queueDate, models.QueueStatusWaiting).
+				Order("number ASC").First(&entry).Error; err != nil {
+				return err
+			}
+
+			calledAt := time.Now()
+			entry.Status = models.QueueStatusCalled
+			entry.CalledAt = &calledAt
+			return tx.Save(&entry).Error
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+					Code:    http.StatusNotFound,
+					Message: "No patients are waiting",
+				})
+			}
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to call next patient",
+			})
+		}
+
+		publishQueue(db, broker, entry.OrganizationID, entry.DoctorID, entry.QueueDate)
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": fmt.Sprintf("Calling queue number %d", entry.Number),
+			"data":    entry,
+		})
+	}
+}
+
+// SkipQueueEntry menandai pasien yang tidak hadir saat dipanggil, atau yang batal sebelum dipanggil
+func SkipQueueEntry(db *gorm.DB, broker *helper.QueueBroker) echo.HandlerFunc {
+	return updateQueueStatus(db, broker, models.QueueStatusSkipped, []string{models.QueueStatusWaiting, models.QueueStatusCalled})
+}
+
+// FinishQueueEntry menandai pasien yang sudah selesai diperiksa
+func FinishQueueEntry(db *gorm.DB, broker *helper.QueueBroker) echo.HandlerFunc {
+	return updateQueueStatus(db, broker, models.QueueStatusDone, []string{models.QueueStatusCalled})
+}
+
+func updateQueueStatus(db *gorm.DB, broker *helper.QueueBroker, status string, allowedFrom []string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		var entry models.QueueEntry
+		if err := scopeQueue(db, doctor, claims).Where("id = ?", c.Param("id")).First(&entry).Error; err != nil {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: "Queue entry not found",
+			})
+		}
+
+		// Status hanya berubah jika masih sama seperti saat dibaca, sehingga aksi ganda dari dua petugas tidak saling menimpa
+		now := time.Now()
+		updates := map[string]interface{}{"status": status}
+		if status == models.QueueStatusDone {
+			updates["finished_at"] = now
+		}
+		result := db.Model(&entry).Where("status IN ?", allowedFrom).Updates(updates)
+		if result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to update queue entry",
+			})
+		}
+		if result.RowsAffected == 0 {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: fmt.Sprintf("Queue entry with status %s cannot be changed to %s", entry.Status, status),
+			})
+		}
+		entry.Status = status
+		if status == models.QueueStatusDone {
+			entry.FinishedAt = &now
+		}
+
+		publishQueue(db, broker, entry.OrganizationID, entry.DoctorID, entry.QueueDate)
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Queue entry updated successfully",
+			"data":    entry,
+		})
+	}
+}
+
+// CreateQueueDisplayToken membuat token untuk layar ruang tunggu. Token ini tidak bisa dipakai untuk endpoint lain.
+func CreateQueueDisplayToken(db *gorm.DB, keys *auth.KeySet) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		var request helper.QueueDoctorRequest
+		_ = c.Bind(&request)
+		if request.DoctorID == 0 {
+			request.DoctorID = doctor.ID
+		}
+		if errorResponse := checkAppointmentDoctor(db, doctor, claims, request.DoctorID); errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		token, expiresAt, err := auth.GenerateQueueDisplayToken(claims.OrganizationID, request.DoctorID, keys)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to create display token",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Display token created successfully",
+			"data": map[string]interface{}{
+				"token":      token,
+				"expires_at": expiresAt,
+				"stream_url": "/api/queue/display/stream?token=" + token,
+			},
+		})
+	}
+}
+
+/*
+StreamQueueDisplay mengirim keadaan antrian sebagai Server-Sent Events. Layar menerima satu event "queue" saat
+terhubung dan setiap kali antrian berubah. Token diambil dari query param karena EventSource di browser tidak bisa
+mengirim header Authorization.
+*/
+func StreamQueueDisplay(db *gorm.DB, keys *auth.KeySet, broker *helper.QueueBroker) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims, err := auth.VerifyQueueDisplayToken(c.QueryParam("token"), keys)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, helper.ErrorResponse{
+				Code:    http.StatusUnauthorized,
+				Message: "Invalid display token",
+			})
+		}
+
+		response := c.Response()
+		response.Header().Set(echo.HeaderContentType, "text/event-stream")
+		response.Header().Set(echo.HeaderCacheControl, "no-cache")
+		response.Header().Set(echo.HeaderConnection, "keep-alive")
+		response.Header().Set("X-Accel-Buffering", "no")
+		response.WriteHeader(http.StatusOK)
+
+		heartbeat := time.NewTicker(queueHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		// Saat tanggal berganti, layar pindah ke antrian hari berikutnya tanpa perlu dimuat ulang
+		for {
+			queueDate := helper.QueueDate(time.Now())
+			updates, unsubscribe := broker.Subscribe(helper.QueueKey(claims.OrganizationID, claims.DoctorID, queueDate))
+
+			display, err := helper.BuildQueueDisplay(db, claims.OrganizationID, claims.DoctorID, queueDate)
+			if err == nil {
+				err = writeQueueEvent(response, display)
+			}
+			if err != nil {
+				unsubscribe()
+				return nil
+			}
+
+			dateChanged := false
+			for !dateChanged {
+				select {
+				case <-c.Request().Context().Done():
+					unsubscribe()
+					return nil
+				case display := <-updates:
+					err = writeQueueEvent(response, display)
+				case <-heartbeat.C:
+					dateChanged = helper.QueueDate(time.Now()) != queueDate
+					if !dateChanged {
+						_, err = fmt.Fprint(response, ": ping\n\n")
+						response.Flush()
+					}
+				}
+				if err != nil {
+					unsubscribe()
+					return nil
+				}
+			}
+			unsubscribe()
+		}
+	}
+}
+
+func writeQueueEvent(response *echo.Response, display helper.QueueDisplay) error {
+	data, err := json.Marshal(display)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(response, "event: queue\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	response.Flush()
+	return nil
+}
+
+// publishQueue mengirim keadaan antrian terbaru ke layar yang terhubung. Kegagalan di sini tidak membatalkan aksi
+// petugas karena layar akan menerima keadaan lengkap pada perubahan berikutnya.
+func publishQueue(db *gorm.DB, broker *helper.QueueBroker, organizationID, doctorID uint, queueDate string) {
+	display, err := helper.BuildQueueDisplay(db, organizationID, doctorID, queueDate)
+	if err != nil {
+		return
+	}
+	broker.Publish(helper.QueueKey(organizationID, doctorID, queueDate), display)
+}
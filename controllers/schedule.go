@@ -0,0 +1,282 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"medis/auth"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GetMyAvailability mengembalikan jadwal praktik mingguan dokter yang login di klinik aktif
+func GetMyAvailability(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		var windows []models.DoctorAvailability
+		if err := db.Where("doctor_id = ? AND organization_id = ?", doctor.ID, claims.OrganizationID).
+			Order("weekday ASC, start_time ASC").Find(&windows).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch availability",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Availability fetched successfully",
+			"data":    windows,
+		})
+	}
+}
+
+func AddMyAvailability(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+		request := c.Get("availability").(helper.AvailabilityRequest)
+
+		// Jadwal tidak boleh bertumpuk dengan jadwal lain milik dokter yang sama, termasuk di klinik lain
+		var overlapping int64
+		if err := db.Model(&models.DoctorAvailability{}).
+			Where("doctor_id = ? AND weekday = ? AND start_time < ? AND end_time > ?", doctor.ID, request.Weekday, request.EndTime, request.StartTime).
+			Count(&overlapping).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to save availability",
+			})
+		}
+		if overlapping > 0 {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: "Availability overlaps with an existing schedule",
+			})
+		}
+
+		window := models.DoctorAvailability{
+			DoctorID:       doctor.ID,
+			OrganizationID: claims.OrganizationID,
+			Weekday:        request.Weekday,
+			StartTime:      request.StartTime,
+			EndTime:        request.EndTime,
+			SlotMinutes:    request.SlotMinutes,
+		}
+		if err := db.Create(&window).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to save availability",
+			})
+		}
+
+		return c.JSON(http.StatusCreated, map[string]interface{}{
+			"code":    http.StatusCreated,
+			"error":   false,
+			"message": "Availability added successfully",
+			"data":    window,
+		})
+	}
+}
+
+// DeleteMyAvailability menghapus jadwal mingguan. Janji temu yang sudah dipesan tidak ikut dibatalkan.
+func DeleteMyAvailability(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		result := db.Where("id = ? AND doctor_id = ? AND organization_id = ?", c.Param("availabilityId"), doctor.ID, claims.OrganizationID).
+			Delete(&models.DoctorAvailability{})
+		if result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to delete availability",
+			})
+		}
+		if result.RowsAffected == 0 {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: "Availability not found",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Availability deleted successfully",
+		})
+	}
+}
+
+func GetMyLeaves(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+
+		today := time.Now().In(helper.ClinicLocation()).Format("2006-01-02")
+		var leaves []models.DoctorLeave
+		if err := db.Where("doctor_id = ? AND date >= ?", doctor.ID, today).Order("date ASC").Find(&leaves).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch leave days",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Leave days fetched successfully",
+			"data":    leaves,
+		})
+	}
+}
+
+/*
+AddMyLeave mencatat hari cuti dokter. Janji temu yang sudah terjadwal pada tanggal tersebut tidak dibatalkan otomatis,
+tetapi dikembalikan di response supaya staf bisa menjadwalkan ulang.
+*/
+func AddMyLeave(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		request := c.Get("leave").(helper.LeaveRequest)
+
+		var existing int64
+		db.Model(&models.DoctorLeave{}).Where("doctor_id = ? AND date = ?", doctor.ID, request.Date).Count(&existing)
+		if existing > 0 {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: "Leave day already recorded",
+			})
+		}
+
+		leave := models.DoctorLeave{
+			DoctorID: doctor.ID,
+			Date:     request.Date,
+			Reason:   request.Reason,
+		}
+		if err := db.Create(&leave).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to save leave day",
+			})
+		}
+
+		dayStart, _ := time.ParseInLocation("2006-01-02", request.Date, helper.ClinicLocation())
+		affected := []models.Appointment{}
+		db.Where("doctor_id = ? AND status = ? AND starts_at >= ? AND starts_at < ?",
+			doctor.ID, models.AppointmentStatusScheduled, dayStart, dayStart.AddDate(0, 0, 1)).
+			Order("starts_at ASC").Find(&affected)
+
+		return c.JSON(http.StatusCreated, map[string]interface{}{
+			"code":    http.StatusCreated,
+			"error":   false,
+			"message": "Leave day added successfully",
+			"data": map[string]interface{}{
+				"leave":                 leave,
+				"affected_appointments": affected,
+			},
+		})
+	}
+}
+
+func DeleteMyLeave(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+
+		result := db.Where("id = ? AND doctor_id = ?", c.Param("leaveId"), doctor.ID).Delete(&models.DoctorLeave{})
+		if result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to delete leave day",
+			})
+		}
+		if result.RowsAffected == 0 {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: "Leave day not found",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Leave day deleted successfully",
+		})
+	}
+}
+
+// GetDoctorSlots mengembalikan slot kosong seorang dokter di klinik aktif pada tanggal tertentu (query param date)
+func GetDoctorSlots(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		doctorID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid doctor ID",
+			})
+		}
+		if errorResponse := checkAppointmentDoctor(db, doctor, claims, uint(doctorID)); errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		location := helper.ClinicLocation()
+		date, err := time.ParseInLocation("2006-01-02", c.QueryParam("date"), location)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Date must be in the format yyyy-mm-dd",
+			})
+		}
+
+		slots := []helper.AppointmentSlot{}
+		var leaveCount int64
+		db.Model(&models.DoctorLeave{}).Where("doctor_id = ? AND date = ?", doctorID, c.QueryParam("date")).Count(&leaveCount)
+		if leaveCount == 0 {
+			var windows []models.DoctorAvailability
+			var booked []models.Appointment
+			err := db.Where("doctor_id = ? AND organization_id = ?", doctorID, claims.OrganizationID).Find(&windows).Error
+			if err == nil {
+				err = db.Where("doctor_id = ? AND status <> ? AND starts_at < ? AND ends_at > ?",
+					doctorID, models.AppointmentStatusCancelled, date.AddDate(0, 0, 1), date).Find(&booked).Error
+			}
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+					Code:    http.StatusInternalServerError,
+					Message: "Failed to fetch available slots",
+				})
+			}
+			slots = helper.FreeSlots(helper.SlotsForDate(windows, date), booked, time.Now())
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Available slots fetched successfully",
+			"data":    slots,
+		})
+	}
+}
+
+// checkAppointmentDoctor memastikan dokter yang dijadwalkan adalah anggota klinik aktif
+func checkAppointmentDoctor(db *gorm.DB, doctor *models.Doctor, claims *auth.Claims, doctorID uint) *helper.ErrorResponse {
+	if claims.OrganizationID == 0 {
+		if doctorID != doctor.ID {
+			return &helper.ErrorResponse{Code: http.StatusNotFound, Message: "Doctor not found in this clinic"}
+		}
+		return nil
+	}
+
+	if _, err := helper.GetMembership(db, claims.OrganizationID, doctorID); err != nil {
+		if errors.Is(err, helper.ErrNotOrganizationMember) {
+			return &helper.ErrorResponse{Code: http.StatusNotFound, Message: "Doctor not found in this clinic"}
+		}
+		return &helper.ErrorResponse{Code: http.StatusInternalServerError, Message: "Failed to fetch doctor"}
+	}
+	return nil
+}
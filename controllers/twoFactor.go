@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+	"time"
+)
+
+func SetupTwoFactor(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+
+		if doctor.TOTPEnabled {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: "Two-factor authentication is already enabled",
+			})
+		}
+
+		// Secret disimpan sebagai pending dan baru aktif setelah dikonfirmasi lewat EnableTwoFactor
+		secret := helper.GenerateTOTPSecret()
+		if err := db.Model(doctor).Updates(map[string]interface{}{
+			"totp_secret":         secret,
+			"totp_last_used_step": 0,
+		}).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to set up two-factor authentication",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Scan the provisioning URI with an authenticator app, then confirm with a code",
+			"data": map[string]interface{}{
+				"secret":           secret,
+				"provisioning_uri": helper.TOTPProvisioningURI(secret, doctor.Email),
+			},
+		})
+	}
+}
+
+func EnableTwoFactor(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+
+		var request helper.TwoFactorCodeRequest
+		if err := c.Bind(&request); err != nil || request.Code == "" {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Authentication code is required",
+			})
+		}
+
+		if doctor.TOTPEnabled {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: "Two-factor authentication is already enabled",
+			})
+		}
+		if doctor.TOTPSecret == "" {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Two-factor authentication has not been set up",
+			})
+		}
+
+		step, ok := helper.ValidateTOTP(doctor.TOTPSecret, request.Code, time.Now())
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, helper.ErrorResponse{
+				Code:    http.StatusUnauthorized,
+				Message: helper.ErrInvalidSecondFactor.Error(),
+			})
+		}
+
+		var recoveryCodes []string
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(doctor).Updates(map[string]interface{}{
+				"totp_enabled":        true,
+				"totp_last_used_step": step,
+			}).Error; err != nil {
+				return err
+			}
+			codes, err := helper.GenerateRecoveryCodes(tx, doctor.ID)
+			recoveryCodes = codes
+			return err
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to enable two-factor authentication",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Two-factor authentication enabled. Store the recovery codes in a safe place, they will not be shown again",
+			"data": map[string]interface{}{
+				"recovery_codes": recoveryCodes,
+			},
+		})
+	}
+}
+
+func DisableTwoFactor(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+
+		var request helper.TwoFactorCodeRequest
+		if err := c.Bind(&request); err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body",
+			})
+		}
+
+		if !doctor.TOTPEnabled {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Two-factor authentication is not enabled",
+			})
+		}
+
+		if err := bcrypt.CompareHashAndPassword([]byte(doctor.Password), []byte(request.Password)); err != nil {
+			return c.JSON(http.StatusUnauthorized, helper.ErrorResponse{
+				Code:    http.StatusUnauthorized,
+				Message: "Invalid password",
+			})
+		}
+
+		if err := helper.VerifySecondFactor(db, doctor, request.Code, request.RecoveryCode); err != nil {
+			return secondFactorErrorResponse(c, err)
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(doctor).Updates(map[string]interface{}{
+				"totp_enabled":        false,
+				"totp_secret":         "",
+				"totp_last_used_step": 0,
+			}).Error; err != nil {
+				return err
+			}
+			return tx.Where("doctor_id = ?", doctor.ID).Delete(&models.RecoveryCode{}).Error
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to disable two-factor authentication",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Two-factor authentication disabled",
+		})
+	}
+}
+
+func RegenerateRecoveryCodes(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+
+		var request helper.TwoFactorCodeRequest
+		if err := c.Bind(&request); err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body",
+			})
+		}
+
+		if !doctor.TOTPEnabled {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Two-factor authentication is not enabled",
+			})
+		}
+
+		// Recovery code baru hanya bisa dibuat dengan kode TOTP, bukan dengan recovery code lama
+		if err := helper.VerifySecondFactor(db, doctor, request.Code, ""); err != nil {
+			return secondFactorErrorResponse(c, err)
+		}
+
+		recoveryCodes, err := helper.GenerateRecoveryCodes(db, doctor.ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to generate recovery codes",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Recovery codes regenerated",
+			"data": map[string]interface{}{
+				"recovery_codes": recoveryCodes,
+			},
+		})
+	}
+}
+
+func secondFactorErrorResponse(c echo.Context, err error) error {
+	if errors.Is(err, helper.ErrInvalidSecondFactor) {
+		return c.JSON(http.StatusUnauthorized, helper.ErrorResponse{
+			Code:    http.StatusUnauthorized,
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+		Code:    http.StatusInternalServerError,
+		Message: "Failed to verify authentication code",
+	})
+}
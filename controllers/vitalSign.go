@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"medis/auth"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Kolom tanda vital yang bisa diminta sebagai tren lewat query param metric
+var vitalSignMetrics = map[string]bool{
+	"systolic_bp":      true,
+	"diastolic_bp":     true,
+	"pulse_rate":       true,
+	"respiratory_rate": true,
+	"temperature":      true,
+	"spo2":             true,
+	"height":           true,
+	"weight":           true,
+	"bmi":              true,
+}
+
+func AddVitalSign(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+		vitalSign := c.Get("vitalSign").(models.VitalSign)
+
+		medicalRecord, errorResponse := findMedicalRecord(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		vitalSign.MedicalRecordID = medicalRecord.ID
+		vitalSign.PatientID = medicalRecord.PatientID
+		vitalSign.OrganizationID = medicalRecord.OrganizationID
+		vitalSign.RecordedByID = doctor.ID
+		if err := db.Create(&vitalSign).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to save vital signs",
+			})
+		}
+
+		return c.JSON(http.StatusCreated, map[string]interface{}{
+			"code":    http.StatusCreated,
+			"error":   false,
+			"message": "Vital signs recorded successfully",
+			"data":    vitalSign,
+			"units":   helper.VitalSignUnits,
+		})
+	}
+}
+
+func GetVitalSigns(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		medicalRecord, errorResponse := findMedicalRecord(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		var vitalSigns []models.VitalSign
+		if err := db.Where("medical_record_id = ?", medicalRecord.ID).Order("measured_at ASC").Find(&vitalSigns).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch vital signs",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Vital signs fetched successfully",
+			"data":    vitalSigns,
+			"units":   helper.VitalSignUnits,
+		})
+	}
+}
+
+func DeleteVitalSign(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		medicalRecord, errorResponse := findMedicalRecord(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		result := db.Where("id = ? AND medical_record_id = ?", c.Param("vitalId"), medicalRecord.ID).Delete(&models.VitalSign{})
+		if result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to delete vital signs",
+			})
+		}
+		if result.RowsAffected == 0 {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: "Vital signs not found",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Vital signs deleted successfully",
+		})
+	}
+}
+
+/*
+GetPatientVitalTrends mengembalikan riwayat tanda vital seorang pasien dari semua kunjungan, urut dari yang terlama.
+Query param from dan to (yyyy-mm-dd) membatasi rentang waktu. Jika metric diisi (misalnya weight atau systolic_bp),
+hanya pengukuran yang memiliki nilai tersebut yang dikembalikan dalam bentuk deret {measured_at, value}.
+*/
+func GetPatientVitalTrends(db *gorm.DB) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doctor := c.Get("doctor").(*models.Doctor)
+		claims := c.Get("claims").(*auth.Claims)
+
+		patient, errorResponse := findPatient(c, db, doctor, claims)
+		if errorResponse != nil {
+			return c.JSON(errorResponse.Code, errorResponse)
+		}
+
+		query := db.Model(&models.VitalSign{}).Where("patient_id = ?", patient.ID)
+
+		// from dan to adalah tanggal di zona waktu klinik, sama seperti filter tanggal janji temu
+		if from := c.QueryParam("from"); from != "" {
+			fromDate, err := time.ParseInLocation("2006-01-02", from, helper.ClinicLocation())
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "from must be in the format yyyy-mm-dd",
+				})
+			}
+			query = query.Where("measured_at >= ?", fromDate)
+		}
+		if to := c.QueryParam("to"); to != "" {
+			toDate, err := time.ParseInLocation("2006-01-02", to, helper.ClinicLocation())
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "to must be in the format yyyy-mm-dd",
+				})
+			}
+			query = query.Where("measured_at < ?", toDate.AddDate(0, 0, 1))
+		}
+
+		metric := c.QueryParam("metric")
+		if metric != "" {
+			if !vitalSignMetrics[metric] {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Unknown vital sign metric",
+				})
+			}
+
+			type trendPoint struct {
+				MeasuredAt      time.Time `json:"measured_at"`
+				MedicalRecordID uint      `json:"medical_record_id"`
+				Value           float64   `json:"value"`
+			}
+			var points []trendPoint
+			// metric sudah dicocokkan dengan daftar kolom di atas sehingga aman dipakai sebagai nama kolom
+			err := query.Select("measured_at, medical_record_id, " + metric + " AS value").
+				Where(metric + " IS NOT NULL").
+				Order("measured_at ASC").
+				Scan(&points).Error
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+					Code:    http.StatusInternalServerError,
+					Message: "Failed to fetch vital sign trends",
+				})
+			}
+
+			return c.JSON(http.StatusOK, map[string]interface{}{
+				"code":    http.StatusOK,
+				"error":   false,
+				"message": "Vital sign trends fetched successfully",
+				"data": map[string]interface{}{
+					"metric": metric,
+					"unit":   helper.VitalSignUnits[metric],
+					"points": points,
+				},
+			})
+		}
+
+		var vitalSigns []models.VitalSign
+		if err := query.Order("measured_at ASC").Find(&vitalSigns).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to fetch vital sign trends",
+			})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"code":    http.StatusOK,
+			"error":   false,
+			"message": "Vital sign trends fetched successfully",
+			"data":    vitalSigns,
+			"units":   helper.VitalSignUnits,
+		})
+	}
+}
+
+// findMedicalRecord membaca :id dari path dan mengambil rekam medis di klinik aktif
+func findMedicalRecord(c echo.Context, db *gorm.DB, doctor *models.Doctor, claims *auth.Claims) (*models.MedicalRecords, *helper.ErrorResponse) {
+	recordID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, &helper.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid record ID"}
+	}
+
+	var medicalRecord models.MedicalRecords
+	if err := scopeMedicalRecords(db, doctor, claims).Where("id = ?", recordID).First(&medicalRecord).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &helper.ErrorResponse{Code: http.StatusNotFound, Message: "Medical record not found or access denied"}
+		}
+		return nil, &helper.ErrorResponse{Code: http.StatusInternalServerError, Message: "Failed to fetch medical record"}
+	}
+	return &medicalRecord, nil
+}
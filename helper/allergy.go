@@ -0,0 +1,71 @@
+package helper
+
+import (
+	"gorm.io/gorm"
+	"medis/models"
+	"strings"
+)
+
+// Panjang minimal alasan override supaya dokter menuliskan pertimbangan klinis, bukan sekadar "ok"
+const MinAllergyOverrideReasonLength = 10
+
+var AllergySeverities = map[string]bool{
+	models.AllergySeverityMild:            true,
+	models.AllergySeverityModerate:        true,
+	models.AllergySeveritySevere:          true,
+	models.AllergySeverityLifeThreatening: true,
+}
+
+// AllergyConflict menjelaskan baris resep yang cocok dengan alergi pasien
+type AllergyConflict struct {
+	Line        int    `json:"line"`
+	ProductName string `json:"product_name"`
+	KFACode     string `json:"kfa_code"`
+	AllergyID   uint   `json:"allergy_id"`
+	Substance   string `json:"substance"`
+	Reaction    string `json:"reaction"`
+	Severity    string `json:"severity"`
+}
+
+/*
+MatchAllergies mencocokkan setiap baris resep dengan daftar alergi. Sebuah baris dianggap cocok jika kode KFA-nya sama,
+atau nama zat alergen muncul di nama produk (tanpa membedakan huruf besar/kecil), misalnya alergi "amoxicillin"
+cocok dengan "Amoxicillin 500 mg Kapsul".
+*/
+func MatchAllergies(allergies []models.PatientAllergy, items []models.PrescriptionItem) []AllergyConflict {
+	var conflicts []AllergyConflict
+	for i, item := range items {
+		productName := strings.ToLower(item.ProductName)
+		for _, allergy := range allergies {
+			substance := strings.ToLower(strings.TrimSpace(allergy.Substance))
+			matchesCode := allergy.KFACode != "" && allergy.KFACode == item.KFACode
+			matchesSubstance := substance != "" && strings.Contains(productName, substance)
+			if !matchesCode && !matchesSubstance {
+				continue
+			}
+			conflicts = append(conflicts, AllergyConflict{
+				Line:        i + 1,
+				ProductName: item.ProductName,
+				KFACode:     item.KFACode,
+				AllergyID:   allergy.ID,
+				Substance:   allergy.Substance,
+				Reaction:    allergy.Reaction,
+				Severity:    allergy.Severity,
+			})
+		}
+	}
+	return conflicts
+}
+
+// FindAllergyConflicts memuat alergi pasien lalu mencocokkannya dengan baris resep
+func FindAllergyConflicts(db *gorm.DB, patientID uint, items []models.PrescriptionItem) ([]AllergyConflict, error) {
+	if patientID == 0 || len(items) == 0 {
+		return nil, nil
+	}
+
+	var allergies []models.PatientAllergy
+	if err := db.Where("patient_id = ?", patientID).Find(&allergies).Error; err != nil {
+		return nil, err
+	}
+	return MatchAllergies(allergies, items), nil
+}
@@ -0,0 +1,206 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"medis/models"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Batas panjang slot janji temu dalam menit
+const (
+	DefaultSlotMinutes = 15
+	MinSlotMinutes     = 5
+	MaxSlotMinutes     = 240
+)
+
+/*
+Pengingat janji temu dikirim APPOINTMENT_REMINDER_HOURS (default 24) jam sebelum janji temu. Janji temu yang dipesan
+lebih mendadak langsung diberi pengingat, kecuali jika waktunya kurang dari minAppointmentReminderLead lagi.
+*/
+const (
+	DefaultAppointmentReminderLead = 24 * time.Hour
+	minAppointmentReminderLead     = time.Hour
+)
+
+// Kode error PostgreSQL untuk pelanggaran exclusion constraint
+const exclusionViolationCode = "23P01"
+
+var clockTimePattern = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`)
+
+// AppointmentSlot adalah satu slot janji temu pada rentang [StartsAt, EndsAt)
+type AppointmentSlot struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// InvalidAppointmentError menandai slot yang tidak bisa dipesan, berbeda dengan kesalahan database
+type InvalidAppointmentError struct {
+	Message string
+}
+
+func (e *InvalidAppointmentError) Error() string {
+	return e.Message
+}
+
+func invalidAppointment(message string) error {
+	return &InvalidAppointmentError{Message: message}
+}
+
+// ClinicLocation mengembalikan zona waktu jadwal praktik (CLINIC_TIMEZONE, default Asia/Jakarta)
+func ClinicLocation() *time.Location {
+	name := os.Getenv("CLINIC_TIMEZONE")
+	if name == "" {
+		name = "Asia/Jakarta"
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		// Server tanpa tzdata tetap memakai WIB
+		return time.FixedZone("WIB", 7*60*60)
+	}
+	return location
+}
+
+// ParseClockTime mengubah jam HH:MM menjadi jumlah menit sejak tengah malam
+func ParseClockTime(value string) (int, bool) {
+	if !clockTimePattern.MatchString(value) {
+		return 0, false
+	}
+	clock, _ := time.Parse("15:04", value)
+	return clock.Hour()*60 + clock.Minute(), true
+}
+
+// SlotsForDate membagi jadwal praktik yang berlaku pada tanggal tersebut menjadi slot, diurutkan dari yang paling awal
+func SlotsForDate(windows []models.DoctorAvailability, date time.Time) []AppointmentSlot {
+	location := ClinicLocation()
+	date = date.In(location)
+	midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, location)
+
+	slots := []AppointmentSlot{}
+	for _, window := range windows {
+		if window.Weekday != int(date.Weekday()) || window.SlotMinutes <= 0 {
+			continue
+		}
+		start, _ := ParseClockTime(window.StartTime)
+		end, _ := ParseClockTime(window.EndTime)
+		for minute := start; minute+window.SlotMinutes <= end; minute += window.SlotMinutes {
+			startsAt := midnight.Add(time.Duration(minute) * time.Minute)
+			slots = append(slots, AppointmentSlot{
+				StartsAt: startsAt,
+				EndsAt:   startsAt.Add(time.Duration(window.SlotMinutes) * time.Minute),
+			})
+		}
+	}
+
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].StartsAt.Before(slots[j].StartsAt)
+	})
+	return slots
+}
+
+// FreeSlots membuang slot yang sudah lewat atau bertumpuk dengan janji temu yang sudah ada
+func FreeSlots(slots []AppointmentSlot, booked []models.Appointment, now time.Time) []AppointmentSlot {
+	free := []AppointmentSlot{}
+	for _, slot := range slots {
+		if !slot.StartsAt.After(now) {
+			continue
+		}
+		taken := false
+		for _, appointment := range booked {
+			if slot.StartsAt.Before(appointment.EndsAt) && appointment.StartsAt.Before(slot.EndsAt) {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			free = append(free, slot)
+		}
+	}
+	return free
+}
+
+/*
+ResolveAppointmentSlot memastikan startsAt tepat di awal salah satu slot jadwal praktik dokter di klinik tersebut,
+bukan di masa lalu, dan bukan pada hari cuti dokter. Bentrok dengan janji temu lain tidak diperiksa di sini
+karena sudah dijaga oleh exclusion constraint di database.
+*/
+func ResolveAppointmentSlot(db *gorm.DB, doctorID, organizationID uint, startsAt, now time.Time) (AppointmentSlot, error) {
+	if startsAt.IsZero() {
+		return AppointmentSlot{}, invalidAppointment("Appointment start time is required")
+	}
+	if !startsAt.After(now) {
+		return AppointmentSlot{}, invalidAppointment("Appointment must be in the future")
+	}
+
+	date := startsAt.In(ClinicLocation()).Format("2006-01-02")
+	var leaveCount int64
+	if err := db.Model(&models.DoctorLeave{}).Where("doctor_id = ? AND date = ?", doctorID, date).Count(&leaveCount).Error; err != nil {
+		return AppointmentSlot{}, err
+	}
+	if leaveCount > 0 {
+		return AppointmentSlot{}, invalidAppointment(fmt.Sprintf("Doctor is on leave on %s", date))
+	}
+
+	var windows []models.DoctorAvailability
+	if err := db.Where("doctor_id = ? AND organization_id = ?", doctorID, organizationID).Find(&windows).Error; err != nil {
+		return AppointmentSlot{}, err
+	}
+	for _, slot := range SlotsForDate(windows, startsAt) {
+		if slot.StartsAt.Equal(startsAt) {
+			return slot, nil
+		}
+	}
+	return AppointmentSlot{}, invalidAppointment("Start time does not match any of the doctor's available slots")
+}
+
+// IsAppointmentConflict mengenali penolakan dari exclusion constraint appointments_no_overlap
+func IsAppointmentConflict(err error) bool {
+	var pgError *pgconn.PgError
+	return errors.As(err, &pgError) && pgError.Code == exclusionViolationCode
+}
+
+// AppointmentReminderTime menghitung kapan pengingat dikirim. ok bernilai false jika janji temu terlalu dekat.
+func AppointmentReminderTime(startsAt, now time.Time) (time.Time, bool) {
+	lead := DefaultAppointmentReminderLead
+	if hours, err := strconv.Atoi(os.Getenv("APPOINTMENT_REMINDER_HOURS")); err == nil && hours > 0 {
+		lead = time.Duration(hours) * time.Hour
+	}
+	if startsAt.Sub(now) < minAppointmentReminderLead {
+		return time.Time{}, false
+	}
+	sendAt := startsAt.Add(-lead)
+	if sendAt.Before(now) {
+		sendAt = now
+	}
+	return sendAt, true
+}
+
+// ScheduleAppointmentReminder mengganti pengingat janji temu yang masih menunggu dengan pengingat sesuai jadwal terbaru.
+// Dipanggil di dalam transaksi yang sama dengan penyimpanan janji temu.
+func ScheduleAppointmentReminder(tx *gorm.DB, appointment *models.Appointment, patient *models.Patient, now time.Time) error {
+	if err := CancelAppointmentReminders(tx, appointment.ID); err != nil {
+		return err
+	}
+	sendAt, ok := AppointmentReminderTime(appointment.StartsAt, now)
+	if !ok {
+		return nil
+	}
+	_, err := EnqueuePatientNotification(tx, models.EmailKindAppointmentReminder, patient, EmailPayload{
+		Name:          patient.Name,
+		AppointmentID: appointment.ID,
+	}, sendAt)
+	return err
+}
+
+// CancelAppointmentReminders menghapus pengingat yang belum terkirim saat janji temu dibatalkan, diubah atau selesai
+func CancelAppointmentReminders(tx *gorm.DB, appointmentID uint) error {
+	return tx.Where("kind = ? AND status = ? AND payload->>'appointment_id' = ?",
+		models.EmailKindAppointmentReminder, models.EmailStatusPending, strconv.FormatUint(uint64(appointmentID), 10)).
+		Delete(&models.OutboxEmail{}).Error
+}
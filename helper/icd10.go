@@ -0,0 +1,128 @@
+package helper
+
+import (
+	"encoding/csv"
+	"fmt"
+	"gorm.io/gorm"
+	"io"
+	"medis/models"
+	"os"
+	"strings"
+)
+
+// Batas jumlah kode ICD-10 sekunder per rekam medis
+const MaxSecondaryICD10Codes = 10
+
+// Karakter khusus pola LIKE di Postgres. Backslash adalah karakter escape bawaan LIKE dan ILIKE.
+var likePatternEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func NormalizeICD10Code(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// EscapeLikePattern membuat input pengguna dicocokkan apa adanya di dalam pola LIKE, misalnya "50%" tidak menjadi wildcard
+func EscapeLikePattern(value string) string {
+	return likePatternEscaper.Replace(value)
+}
+
+// LoadICD10File membaca file CSV dengan header code,description
+func LoadICD10File(path string) ([]models.ICD10Code, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 2
+	if _, err := reader.Read(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var codes []models.ICD10Code
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		codes = append(codes, models.ICD10Code{
+			Code:        NormalizeICD10Code(row[0]),
+			Description: strings.TrimSpace(row[1]),
+		})
+	}
+	return codes, nil
+}
+
+/*
+ValidateRecordDiagnoses menormalkan kode ICD-10 primer dan sekunder lalu memastikan semuanya ada di tabel icd10_codes.
+Kode harus terdaftar persis: tabel bawaan berisi semua kategori tiga karakter dan sub-kategori yang umum dipakai, jadi
+sub-kategori lain ditolak dan diagnosisnya dicatat dengan kode kategori. Daftar lengkap bisa dipasang lewat
+ICD10_DATA_FILE. Kode sekunder tidak boleh duplikat, tidak boleh sama dengan kode primer, dan hanya boleh diisi jika
+kode primer ada.
+*/
+func ValidateRecordDiagnoses(db *gorm.DB, primary string, secondary []models.MedicalRecordDiagnosis) (string, []models.MedicalRecordDiagnosis, error) {
+	primary = NormalizeICD10Code(primary)
+	if primary == "" && len(secondary) > 0 {
+		return "", nil, invalidDiagnosis("Primary ICD-10 code is required when secondary codes are given")
+	}
+	if len(secondary) > MaxSecondaryICD10Codes {
+		return "", nil, invalidDiagnosis(fmt.Sprintf("At most %d secondary ICD-10 codes are allowed", MaxSecondaryICD10Codes))
+	}
+
+	codes := []string{}
+	if primary != "" {
+		codes = append(codes, primary)
+	}
+	seen := map[string]bool{primary: true}
+	normalized := make([]models.MedicalRecordDiagnosis, 0, len(secondary))
+	for _, diagnosis := range secondary {
+		code := NormalizeICD10Code(diagnosis.ICD10Code)
+		if code == "" {
+			return "", nil, invalidDiagnosis("Secondary ICD-10 code cannot be empty")
+		}
+		if seen[code] {
+			return "", nil, invalidDiagnosis(fmt.Sprintf("ICD-10 code %s is listed more than once", code))
+		}
+		seen[code] = true
+		codes = append(codes, code)
+		normalized = append(normalized, models.MedicalRecordDiagnosis{ICD10Code: code})
+	}
+	if len(codes) == 0 {
+		return "", normalized, nil
+	}
+
+	var known []string
+	if err := db.Model(&models.ICD10Code{}).Where("code IN ?", codes).Pluck("code", &known).Error; err != nil {
+		return "", nil, err
+	}
+	knownCodes := map[string]bool{}
+	for _, code := range known {
+		knownCodes[code] = true
+	}
+	var unknown []string
+	for _, code := range codes {
+		if !knownCodes[code] {
+			unknown = append(unknown, code)
+		}
+	}
+	if len(unknown) > 0 {
+		return "", nil, invalidDiagnosis("Unknown ICD-10 code: " + strings.Join(unknown, ", "))
+	}
+	return primary, normalized, nil
+}
+
+// InvalidDiagnosisError menandai kesalahan input kode diagnosis, berbeda dengan kesalahan database
+type InvalidDiagnosisError struct {
+	Message string
+}
+
+func (e *InvalidDiagnosisError) Error() string {
+	return e.Message
+}
+
+func invalidDiagnosis(message string) error {
+	return &InvalidDiagnosisError{Message: message}
+}
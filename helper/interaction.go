@@ -0,0 +1,149 @@
+package helper
+
+import (
+	"encoding/csv"
+	"fmt"
+	"gorm.io/gorm"
+	"io"
+	"medis/models"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Urutan tingkat keparahan interaksi, dipakai untuk validasi dataset dan pengurutan peringatan
+var InteractionSeverityRank = map[string]int{
+	models.InteractionSeverityMinor:           1,
+	models.InteractionSeverityModerate:        2,
+	models.InteractionSeverityMajor:           3,
+	models.InteractionSeverityContraindicated: 4,
+}
+
+// InteractionWarning menjelaskan pasangan baris resep yang cocok dengan sebuah aturan interaksi
+type InteractionWarning struct {
+	Lines       [2]int    `json:"lines"`
+	Products    [2]string `json:"products"`
+	Severity    string    `json:"severity"`
+	Description string    `json:"description"`
+	Management  string    `json:"management"`
+}
+
+// NormalizeInteractionKey menyeragamkan kode KFA atau nama bahan aktif pada aturan interaksi
+func NormalizeInteractionKey(key string) string {
+	return strings.ToLower(strings.TrimSpace(key))
+}
+
+// LoadDrugInteractionFile membaca file CSV dengan header drug_a,drug_b,severity,description,management
+func LoadDrugInteractionFile(path string) ([]models.DrugInteraction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 5
+	if _, err := reader.Read(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var interactions []models.DrugInteraction
+	seen := map[[2]string]bool{}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		drugA, drugB := NormalizeInteractionKey(row[0]), NormalizeInteractionKey(row[1])
+		line, _ := reader.FieldPos(0)
+		if drugA == "" || drugB == "" || drugA == drugB {
+			return nil, fmt.Errorf("%s:%d: an interaction needs two different drugs", path, line)
+		}
+		if drugA > drugB {
+			drugA, drugB = drugB, drugA
+		}
+		severity := strings.ToLower(strings.TrimSpace(row[2]))
+		if InteractionSeverityRank[severity] == 0 {
+			return nil, fmt.Errorf("%s:%d: unknown severity %q", path, line, row[2])
+		}
+		// Pasangan yang sama ditulis dua kali berarti dataset salah, bukan dua aturan berbeda
+		pair := [2]string{drugA, drugB}
+		if seen[pair] {
+			return nil, fmt.Errorf("%s:%d: duplicate interaction %s and %s", path, line, drugA, drugB)
+		}
+		seen[pair] = true
+
+		interactions = append(interactions, models.DrugInteraction{
+			DrugA:       drugA,
+			DrugB:       drugB,
+			Severity:    severity,
+			Description: strings.TrimSpace(row[3]),
+			Management:  strings.TrimSpace(row[4]),
+		})
+	}
+	return interactions, nil
+}
+
+/*
+matchesInteractionDrug memeriksa apakah baris resep cocok dengan salah satu sisi aturan. Kunci berupa 8 digit
+dicocokkan dengan kode KFA, selain itu dianggap nama bahan aktif yang harus muncul di nama produk.
+*/
+func matchesInteractionDrug(item models.PrescriptionItem, key string) bool {
+	if ValidateKFACode(key) {
+		return item.KFACode == key
+	}
+	return strings.Contains(strings.ToLower(item.ProductName), key)
+}
+
+/*
+MatchInteractions memeriksa setiap pasangan baris resep terhadap daftar aturan interaksi. Peringatan diurutkan
+dari tingkat keparahan tertinggi, lalu berdasarkan nomor baris.
+*/
+func MatchInteractions(rules []models.DrugInteraction, items []models.PrescriptionItem) []InteractionWarning {
+	var warnings []InteractionWarning
+	for i := 0; i < len(items); i++ {
+		for j := i + 1; j < len(items); j++ {
+			for _, rule := range rules {
+				forward := matchesInteractionDrug(items[i], rule.DrugA) && matchesInteractionDrug(items[j], rule.DrugB)
+				backward := matchesInteractionDrug(items[i], rule.DrugB) && matchesInteractionDrug(items[j], rule.DrugA)
+				if !forward && !backward {
+					continue
+				}
+				warnings = append(warnings, InteractionWarning{
+					Lines:       [2]int{i + 1, j + 1},
+					Products:    [2]string{items[i].ProductName, items[j].ProductName},
+					Severity:    rule.Severity,
+					Description: rule.Description,
+					Management:  rule.Management,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(warnings, func(a, b int) bool {
+		return InteractionSeverityRank[warnings[a].Severity] > InteractionSeverityRank[warnings[b].Severity]
+	})
+	return warnings
+}
+
+// FindDrugInteractions memuat aturan interaksi lalu mencocokkannya dengan baris resep
+func FindDrugInteractions(db *gorm.DB, items []models.PrescriptionItem) ([]InteractionWarning, error) {
+	if len(items) < 2 {
+		return []InteractionWarning{}, nil
+	}
+
+	var rules []models.DrugInteraction
+	if err := db.Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	warnings := MatchInteractions(rules, items)
+	if warnings == nil {
+		warnings = []InteractionWarning{}
+	}
+	return warnings, nil
+}
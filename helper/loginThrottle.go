@@ -0,0 +1,88 @@
+package helper
+
+import (
+	"gorm.io/gorm"
+	"medis/models"
+	"time"
+)
+
+// Batas kegagalan sebelum dikunci. Batas per IP lebih longgar karena satu klinik bisa berbagi satu IP publik.
+const (
+	usernameLockThreshold = 5
+	ipLockThreshold       = 20
+	baseLockoutDuration   = 1 * time.Minute
+	maxLockoutDuration    = 1 * time.Hour
+	failureWindow         = 24 * time.Hour
+)
+
+func usernameAttemptKey(username string) string {
+	return "username:" + username
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LoginLockedUntil mengembalikan waktu berakhirnya kunci jika username atau IP sedang dikunci
+func LoginLockedUntil(db *gorm.DB, username, ip string) *time.Time {
+	var attempts []models.LoginAttempt
+	db.Where("attempt_key IN ? AND locked_until > ?", []string{usernameAttemptKey(username), ipAttemptKey(ip)}, time.Now()).
+		Find(&attempts)
+
+	var lockedUntil *time.Time
+	for _, attempt := range attempts {
+		if lockedUntil == nil || attempt.LockedUntil.After(*lockedUntil) {
+			lockedUntil = attempt.LockedUntil
+		}
+	}
+	return lockedUntil
+}
+
+// RecordLoginFailure menambah counter username dan IP. Nilai kembalian true berarti
+// username baru saja terkunci pada percobaan ini sehingga pemilik akun perlu diberi tahu.
+func RecordLoginFailure(db *gorm.DB, username, ip string) (*time.Time, bool) {
+	usernameLockedUntil, usernameFailures := recordFailure(db, usernameAttemptKey(username), usernameLockThreshold)
+	recordFailure(db, ipAttemptKey(ip), ipLockThreshold)
+	return usernameLockedUntil, usernameFailures == usernameLockThreshold
+}
+
+func recordFailure(db *gorm.DB, key string, threshold int) (*time.Time, int) {
+	now := time.Now()
+
+	// Upsert atomik supaya percobaan login paralel tidak saling menimpa counter
+	var failedCount int
+	err := db.Raw(`
+		INSERT INTO login_attempts (attempt_key, failed_count, last_failed_at, updated_at)
+		VALUES (?, 1, ?, ?)
+		ON CONFLICT (attempt_key) DO UPDATE SET
+			failed_count = CASE
+				WHEN login_attempts.last_failed_at IS NULL OR login_attempts.last_failed_at < ? THEN 1
+				ELSE login_attempts.failed_count + 1
+			END,
+			last_failed_at = EXCLUDED.last_failed_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING failed_count`, key, now, now, now.Add(-failureWindow)).Scan(&failedCount).Error
+	if err != nil || failedCount < threshold {
+		return nil, failedCount
+	}
+
+	// Exponential backoff: setiap kegagalan setelah batas menggandakan durasi kunci
+	lockout := baseLockoutDuration
+	for i := threshold; i < failedCount && lockout < maxLockoutDuration; i++ {
+		lockout *= 2
+	}
+	if lockout > maxLockoutDuration {
+		lockout = maxLockoutDuration
+	}
+
+	lockedUntil := now.Add(lockout)
+	db.Model(&models.LoginAttempt{}).Where("attempt_key = ?", key).Update("locked_until", lockedUntil)
+	return &lockedUntil, failedCount
+}
+
+// ResetLoginFailures dipanggil setelah login berhasil. Counter IP sengaja dibiarkan berjalan sampai window habis.
+func ResetLoginFailures(db *gorm.DB, username string) {
+	db.Model(&models.LoginAttempt{}).
+		Where("attempt_key = ?", usernameAttemptKey(username)).
+		Updates(map[string]interface{}{"failed_count": 0, "locked_until": nil})
+}
@@ -0,0 +1,163 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"medis/models"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backend penyedia SMS/WhatsApp yang bisa dipilih lewat MESSAGING_PROVIDER
+const (
+	MessagingProviderHTTP   = "http"
+	MessagingProviderMemory = "memory"
+)
+
+const messagingRequestTimeout = 10 * time.Second
+
+// TextMessage adalah SMS atau pesan WhatsApp. To selalu dalam format E.164.
+type TextMessage struct {
+	Channel string `json:"channel"`
+	To      string `json:"to"`
+	Body    string `json:"body"`
+}
+
+// MessagingProvider mengirim pesan SMS dan WhatsApp. Seperti Notifier, implementasinya dipilih saat aplikasi dijalankan.
+type MessagingProvider interface {
+	SendMessage(message TextMessage) error
+}
+
+func IsMessagingChannel(channel string) bool {
+	return channel == models.ChannelSMS || channel == models.ChannelWhatsApp
+}
+
+func IsNotificationChannel(channel string) bool {
+	return channel == models.ChannelEmail || IsMessagingChannel(channel)
+}
+
+/*
+NewMessagingProviderFromEnv memilih penyedia pesan dari MESSAGING_PROVIDER:
+http -> kirim ke gateway di MESSAGING_API_URL dengan token MESSAGING_API_KEY
+memory -> simpan pesan di memori, untuk pengujian
+Jika kosong, pengiriman SMS dan WhatsApp dinonaktifkan dan notifikasi pasien dialihkan ke email (lihat outboxEmailFallback).
+*/
+func NewMessagingProviderFromEnv() (MessagingProvider, error) {
+	switch provider := os.Getenv("MESSAGING_PROVIDER"); provider {
+	case "":
+		return nil, nil
+	case MessagingProviderHTTP:
+		baseURL := os.Getenv("MESSAGING_API_URL")
+		if baseURL == "" {
+			return nil, fmt.Errorf("MESSAGING_API_URL is not set")
+		}
+		return NewHTTPMessagingProvider(baseURL, os.Getenv("MESSAGING_API_KEY")), nil
+	case MessagingProviderMemory:
+		return NewMemoryMessagingProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown MESSAGING_PROVIDER %q", provider)
+	}
+}
+
+/*
+HTTPMessagingProvider mengirim pesan sebagai JSON ke POST <BaseURL>/messages. Gateway membalas 2xx jika pesan
+diterima. Balasan 4xx selain 429 dianggap permanen (misalnya nomor ditolak) sehingga tidak dicoba ulang.
+*/
+type HTTPMessagingProvider struct {
+	BaseURL string
+	APIKey  string
+	client  *http.Client
+}
+
+func NewHTTPMessagingProvider(baseURL, apiKey string) *HTTPMessagingProvider {
+	return &HTTPMessagingProvider{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		APIKey:  apiKey,
+		client:  &http.Client{Timeout: messagingRequestTimeout},
+	}
+}
+
+func (p *HTTPMessagingProvider) SendMessage(message TextMessage) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPost, p.BaseURL+"/messages", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		request.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return nil
+	}
+
+	detail, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+	err = fmt.Errorf("messaging gateway returned %d: %s", response.StatusCode, strings.TrimSpace(string(detail)))
+	if response.StatusCode >= 400 && response.StatusCode < 500 && response.StatusCode != http.StatusTooManyRequests {
+		return &permanentDeliveryError{message: err.Error()}
+	}
+	return err
+}
+
+// MemoryMessagingProvider menyimpan pesan yang dikirim di memori supaya pengujian bisa memeriksa isinya
+type MemoryMessagingProvider struct {
+	mu       sync.Mutex
+	messages []TextMessage
+}
+
+func NewMemoryMessagingProvider() *MemoryMessagingProvider {
+	return &MemoryMessagingProvider{}
+}
+
+func (p *MemoryMessagingProvider) SendMessage(message TextMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+// Messages mengembalikan salinan pesan yang sudah dikirim, urut sesuai waktu pengiriman
+func (p *MemoryMessagingProvider) Messages() []TextMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]TextMessage(nil), p.messages...)
+}
+
+func (p *MemoryMessagingProvider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = nil
+}
+
+/*
+PatientContact menentukan kanal dan alamat tujuan notifikasi pasien. Kanal pilihan pasien dipakai jika datanya
+tersedia. Jika tidak, email dipakai sebagai cadangan, lalu SMS bagi pasien tanpa email. ok bernilai false jika
+pasien tidak bisa dihubungi sama sekali.
+*/
+func PatientContact(patient *models.Patient) (channel, recipient string, ok bool) {
+	phone, phoneErr := NormalizePhoneNumber(patient.PhoneNumber)
+	if IsMessagingChannel(patient.PreferredChannel) && phoneErr == nil {
+		return patient.PreferredChannel, phone, true
+	}
+	if patient.Email != "" {
+		return models.ChannelEmail, patient.Email, true
+	}
+	if phoneErr == nil {
+		return models.ChannelSMS, phone, true
+	}
+	return "", "", false
+}
@@ -0,0 +1,87 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Kode provinsi (dua digit pertama NIK) sesuai kode wilayah Kemendagri
+var nikProvinceCodes = map[string]bool{
+	"11": true, "12": true, "13": true, "14": true, "15": true, "16": true, "17": true, "18": true, "19": true,
+	"21": true,
+	"31": true, "32": true, "33": true, "34": true, "35": true, "36": true,
+	"51": true, "52": true, "53": true,
+	"61": true, "62": true, "63": true, "64": true, "65": true,
+	"71": true, "72": true, "73": true, "74": true, "75": true, "76": true,
+	"81": true, "82": true,
+	"91": true, "92": true, "93": true, "94": true, "95": true, "96": true,
+}
+
+// Jenis kelamin pasien, dipakai untuk memeriksa segmen tanggal lahir pada NIK
+const (
+	GenderMale   = "male"
+	GenderFemale = "female"
+)
+
+var (
+	nikPattern  = regexp.MustCompile(`^\d{16}$`)
+	bpjsPattern = regexp.MustCompile(`^\d{13}$`)
+)
+
+func ValidateBPJSNumber(number string) bool {
+	return bpjsPattern.MatchString(number)
+}
+
+/*
+ValidateNIK memeriksa struktur NIK 16 digit:
+  - digit 1-6 adalah kode wilayah (provinsi, kabupaten/kota, kecamatan) dan tidak boleh 00
+  - digit 7-12 adalah tanggal lahir DDMMYY, untuk perempuan tanggal ditambah 40
+  - digit 13-16 adalah nomor urut dan tidak boleh 0000
+
+birthDate (yyyy-mm-dd) wajib, gender boleh kosong. Jika gender kosong, tanggal dengan atau tanpa
+tambahan 40 sama-sama diterima.
+*/
+func ValidateNIK(nik, birthDate, gender string) error {
+	if !nikPattern.MatchString(nik) {
+		return errors.New("NIK must be exactly 16 digits")
+	}
+
+	if !nikProvinceCodes[nik[0:2]] || nik[2:4] == "00" || nik[4:6] == "00" {
+		return errors.New("NIK region code is not valid")
+	}
+
+	if nik[12:16] == "0000" {
+		return errors.New("NIK serial number is not valid")
+	}
+
+	birth, err := time.Parse("2006-01-02", birthDate)
+	if err != nil {
+		return errors.New("Birth date is required to validate NIK")
+	}
+
+	day, _ := strconv.Atoi(nik[6:8])
+	female := day > 40
+	if female {
+		day -= 40
+	}
+	if gender == GenderMale && female || gender == GenderFemale && !female {
+		return fmt.Errorf("NIK birth-date segment does not match gender %s", gender)
+	}
+
+	if nik[8:12] != birth.Format("0106") || day != birth.Day() {
+		return errors.New("NIK birth-date segment does not match birth date")
+	}
+	return nil
+}
+
+// GenderFromNIK menebak jenis kelamin dari segmen tanggal lahir NIK yang sudah divalidasi
+func GenderFromNIK(nik string) string {
+	day, _ := strconv.Atoi(nik[6:8])
+	if day > 40 {
+		return GenderFemale
+	}
+	return GenderMale
+}
@@ -0,0 +1,57 @@
+package helper
+
+import "testing"
+
+func TestValidateNIK(t *testing.T) {
+	tests := []struct {
+		name      string
+		nik       string
+		birthDate string
+		gender    string
+		wantErr   bool
+	}{
+		{"male", "3174051708900001", "1990-08-17", GenderMale, false},
+		{"female day plus 40", "3174055708900002", "1990-08-17", GenderFemale, false},
+		{"female day plus 40 without gender", "3174055708900002", "1990-08-17", "", false},
+		{"female first of month", "3273014101050003", "2005-01-01", GenderFemale, false},
+		{"female segment for male patient", "3174055708900002", "1990-08-17", GenderMale, true},
+		{"male segment for female patient", "3174051708900001", "1990-08-17", GenderFemale, true},
+		{"birth date mismatch", "3174051708900001", "1990-08-18", GenderMale, true},
+		{"birth year mismatch", "3174051708900001", "1991-08-17", GenderMale, true},
+		{"unknown province", "2074051708900001", "1990-08-17", GenderMale, true},
+		{"zero province", "0074051708900001", "1990-08-17", GenderMale, true},
+		{"zero regency", "3100051708900001", "1990-08-17", GenderMale, true},
+		{"zero district", "3174001708900001", "1990-08-17", GenderMale, true},
+		{"zero serial", "3174051708900000", "1990-08-17", GenderMale, true},
+		{"too short", "317405170890001", "1990-08-17", GenderMale, true},
+		{"not digits", "31740517089O0001", "1990-08-17", GenderMale, true},
+		{"missing birth date", "3174051708900001", "", GenderMale, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateNIK(test.nik, test.birthDate, test.gender)
+			if (err != nil) != test.wantErr {
+				t.Errorf("ValidateNIK(%q, %q, %q) error = %v, want error %v", test.nik, test.birthDate, test.gender, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenderFromNIK(t *testing.T) {
+	tests := []struct {
+		nik  string
+		want string
+	}{
+		{"3174051708900001", GenderMale},
+		{"3174053108900001", GenderMale},
+		{"3174054108900001", GenderFemale},
+		{"3174057108900001", GenderFemale},
+	}
+
+	for _, test := range tests {
+		if got := GenderFromNIK(test.nik); got != test.want {
+			t.Errorf("GenderFromNIK(%q) = %q, want %q", test.nik, got, test.want)
+		}
+	}
+}
@@ -1,316 +1,180 @@
 package helper
 
 import (
-	"github.com/go-gomail/gomail"
-	"io"
-	"os"
-	"strconv"
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"medis/appconfig"
+	"medis/models"
+	"path"
+	"strings"
+	texttemplate "text/template"
+	"time"
 )
 
-func SendWelcomeEmail(doctorEmail, fullName, verificationToken string) error {
-	smtpServer := os.Getenv("SMTP_SERVER")
-	smtpPortStr := os.Getenv("SMTP_PORT")
-	smtpUsername := os.Getenv("SMTP_USERNAME")
-	smtpPassword := os.Getenv("SMTP_PASSWORD")
-
-	sender := smtpUsername
-	recipient := doctorEmail
-	subject := "Welcome to health"
-	verificationLink := "http://35.225.10.188:8080/verify?token=" + verificationToken
-	emailBody := `
-    <html>
-    <head>
-        <link href="https://maxcdn.bootstrapcdn.com/bootstrap/4.5.2/css/bootstrap.min.css" rel="stylesheet">
-        <style>
-            body {
-                font-family: 'Arial', sans-serif;
-                background-color: #f5f5f5;
-                margin: 0;
-                padding: 0;
-            }
-            .container {
-                max-width: 600px;
-                margin: 0 auto;
-                padding: 20px;
-                background-color: #ffffff;
-                box-shadow: 0 0 10px rgba(0, 0, 0, 0.1);
-                border-radius: 5px;
-            }
-            h1 {
-                text-align: center;
-                color: #333;
-            }
-            .message {
-                background-color: #f9f9f9;
-                padding: 15px;
-                border: 1px solid #ddd;
-                border-radius: 5px;
-            }
-            p {
-                font-size: 16px;
-                margin-top: 10px;
-                line-height: 1.6;
-            }
-            strong {
-                font-weight: bold;
-            }
-            .footer {
-                text-align: center;
-                margin-top: 20px;
-                color: #666;
-            }
-            .btn-verify-email {
-                background-color: #1E90FF;
-                color: #fff;
-                padding: 10px 20px;
-                border-radius: 5px;
-                text-decoration: none;
-                display: inline-block;
-                margin: 20px auto;
-            }
-            .btn-verify-email:hover {
-                background-color: #007BFF;
-            }
-            .logo {
-                text-align: center;
-                margin-top: 20px;
-            }
-            .logo img {
-                width: 120px;
-                height: 120px;
-                border-radius: 50%;
-                border: 3px solid #1E90FF;
-                transition: transform 0.3s ease-in-out;
-                margin: 0 auto;
-                display: block;
-            }
-            .logo img:hover {
-                transform: scale(1.1);
-            }
-        </style>
-    </head>
-    <body>
-        <div class="container">
-            <div class="logo">
-                <a href="https://ibb.co.com/txfvnNV"><img src="https://i.ibb.co.com/LJwcBqV/channels4-profile.jpg" alt="channels4-profile" border="0"></a>
-            </div>
-            <h1>Welcome to health</h1>
-            <div class="message">
-                <p>Hello, <strong>` + fullName + `</strong>,</p>
-                <p>Thank you for choosing health. You're now part of our team!</p>
-                <p>If you have any questions or need assistance, please don't hesitate to contact our support team.</p>
-                <p><strong>Support Team:</strong> <a href="mailto:health@gmail.com">health@gmail.com</a></p>
-                <a href="` + verificationLink + `" class="btn btn-verify-email">Verify Email</a>
-            </div>
-            <div class="footer">
-                <p>&copy; 2024 health. All rights reserved.</p>
-            </div>
-        </div>
-    </body>
-    </html>
-    `
-
-	smtpPort, err := strconv.Atoi(smtpPortStr)
-	if err != nil {
-		return err
-	}
-
-	m := gomail.NewMessage()
-	m.SetHeader("From", sender)
-	m.SetHeader("To", recipient)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", emailBody)
+// Bahasa email yang tersedia. Bahasa Indonesia dipakai jika preferensi pengguna kosong atau tidak dikenal.
+const (
+	LocaleIndonesian = "id"
+	LocaleEnglish    = "en"
+	DefaultLocale    = LocaleIndonesian
+)
 
-	d := gomail.NewDialer(smtpServer, smtpPort, smtpUsername, smtpPassword)
+var SupportedLocales = []string{LocaleIndonesian, LocaleEnglish}
 
-	if err := d.DialAndSend(m); err != nil {
-		return err
-	}
+var (
+	ErrUnknownEmailKind   = errors.New("unknown email kind")
+	ErrUnknownMessageKind = errors.New("unknown text message kind")
+)
 
-	return nil
+// Jenis notifikasi yang juga bisa dikirim lewat SMS dan WhatsApp
+var textMessageKinds = []string{models.EmailKindMedicalRecord, models.EmailKindAppointmentReminder}
+
+/*
+Template email disimpan per bahasa di templates/email/<bahasa>/<jenis>.html dan .txt. File .html mengisi blok
+"title" dan "content" pada layout.html, sedangkan file .txt berisi blok "subject" dan "body" untuk versi teks
+biasa. Bagian yang sama di setiap email (footer, kontak dukungan) ada di common.html dan common.txt.
+Pesan SMS dan WhatsApp memakai templates/message/<bahasa>/<jenis>.txt dan dibuat sesingkat mungkin.
+*/
+//go:embed templates/email templates/message
+var emailTemplateFS embed.FS
+
+var emailTemplateFuncs = map[string]interface{}{
+	// Waktu ditampilkan dalam zona waktu klinik supaya sama dengan jam yang dilihat dokter
+	"datetime": func(t time.Time) string {
+		return t.In(ClinicLocation()).Format("2006-01-02 15:04 MST")
+	},
+	"join": strings.Join,
 }
 
-func SendLoginNotification(doctorEmail string, name string) error {
-	smtpServer := os.Getenv("SMTP_SERVER")
-	smtpPortStr := os.Getenv("SMTP_PORT")
-	smtpUsername := os.Getenv("SMTP_USERNAME")
-	smtpPassword := os.Getenv("SMTP_PASSWORD")
-
-	sender := smtpUsername
-	recipient := doctorEmail
-	subject := "Successful Login Notification"
-	emailBody := `
-	<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Login Notification</title>
-    <style>
-        body {
-            font-family: 'Arial', sans-serif;
-            background: linear-gradient(180deg, #007BFF, #00BFFF);
-            color: #fff;
-            margin: 0;
-            padding: 0;
-            display: flex;
-            align-items: center;
-            justify-content: center;
-            height: 100vh;
-        }
-        .container {
-            max-width: 600px;
-            width: 100%;
-            background-color: #fff;
-            box-shadow: 0 0 20px rgba(0, 0, 0, 0.2);
-            border-radius: 10px;
-            overflow: hidden;
-            text-align: center;
-            margin: 0 auto; /* Menempatkan container di tengah */
-        }
-        .header {
-            background-color: #007BFF;
-            color: #fff;
-            padding: 20px;
-            border-bottom: 1px solid #ddd;
-        }
-        h1 {
-            margin: 0;
-            color: #333;
-            font-size: 28px;
-        }
-        .logo {
-            text-align: center;
-            margin-top: 20px;
-        }
-        .logo img {
-            width: 120px;
-            height: 120px;
-            border-radius: 50%;
-            border: 3px solid #007BFF;
-            transition: transform 0.3s ease-in-out;
-        }
-        .logo img:hover {
-            transform: scale(1.1);
-        }
-        .message {
-            padding: 20px;
-        }
-        p {
-            font-size: 18px;
-            margin-top: 15px;
-            color: #555;
-            line-height: 1.5;
-        }
-        .footer {
-            text-align: center;
-            padding: 20px;
-            color: #666;
-            font-size: 14px;
-            border-top: 1px solid #ddd;
-        }
-        a {
-            text-decoration: none;
-            color: #007BFF;
-            font-weight: bold;
-        }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>Login Successful</h1>
-        </div>
-       <div class="logo">
-    <img src="https://ibb.co.com/txfvnNV" alt="health Logo">
-</div>
-
-        <div class="message">
-            <p>Hello, <strong>` + name + `</strong>,</p>
-            <p>Your login was successful. If this wasn't you, please contact our support team immediately. Thank you.</p>
-            <p><strong>Support Team:</strong> <a href="mailto:health@gmail.com">health@gmail.com</a></p>
-        </div>
-        <div class="footer">
-            <p>&copy; 2024 health. All rights reserved. | <a href="https://health.seculab.space" target="_blank">health</a></p>
-        </div>
-    </div>
-</body>
-</html>
-
-
-
+type emailTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
 
-	`
+var (
+	emailTemplates       = mustLoadEmailTemplates()
+	textMessageTemplates = mustLoadTextMessageTemplates()
+)
 
-	smtpPort, err := strconv.Atoi(smtpPortStr)
-	if err != nil {
-		return err
+func mustLoadEmailTemplates() map[string]emailTemplate {
+	templates := map[string]emailTemplate{}
+	for _, locale := range SupportedLocales {
+		for _, kind := range models.EmailKinds {
+			dir := path.Join("templates/email", locale)
+			html := htmltemplate.Must(htmltemplate.New("layout.html").Funcs(emailTemplateFuncs).ParseFS(emailTemplateFS,
+				"templates/email/layout.html", path.Join(dir, "common.html"), path.Join(dir, kind+".html")))
+			text := texttemplate.Must(texttemplate.New(kind+".txt").Funcs(emailTemplateFuncs).ParseFS(emailTemplateFS,
+				path.Join(dir, "common.txt"), path.Join(dir, kind+".txt")))
+			templates[locale+"/"+kind] = emailTemplate{html: html, text: text}
+		}
 	}
+	return templates
+}
 
-	m := gomail.NewMessage()
-	m.SetHeader("From", sender)
-	m.SetHeader("To", recipient)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", emailBody)
+func mustLoadTextMessageTemplates() map[string]*texttemplate.Template {
+	templates := map[string]*texttemplate.Template{}
+	for _, locale := range SupportedLocales {
+		for _, kind := range textMessageKinds {
+			file := path.Join("templates/message", locale, kind+".txt")
+			templates[locale+"/"+kind] = texttemplate.Must(texttemplate.New(kind+".txt").Funcs(emailTemplateFuncs).ParseFS(emailTemplateFS, file))
+		}
+	}
+	return templates
+}
 
-	d := gomail.NewDialer(smtpServer, smtpPort, smtpUsername, smtpPassword)
+// EmailTemplateData adalah isi yang disisipkan ke template email dan pesan. Nilainya di-escape otomatis oleh html/template.
+type EmailTemplateData struct {
+	Locale         string
+	Name           string
+	Link           string
+	LinkExpiresAt  time.Time
+	LockedUntil    time.Time
+	DoctorName     string
+	ClinicName     string
+	AppointmentAt  time.Time
+	VisitAt        time.Time
+	Diagnosis      string
+	Prescriptions  []string
+	CareSuggestion string
+}
 
-	if err := d.DialAndSend(m); err != nil {
-		return err
+// NormalizeLocale mengubah preferensi bahasa seperti "EN" atau "en-US" menjadi bahasa yang didukung
+func NormalizeLocale(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if index := strings.IndexAny(value, "-_"); index >= 0 {
+		value = value[:index]
 	}
-
-	return nil
+	if IsSupportedLocale(value) {
+		return value
+	}
+	return DefaultLocale
 }
 
-func SendMedicalRecordNotification(patientEmail, patientName, diagnosis, prescription, careSuggestion string) error {
-	pdfBytes, err := GenerateMedicalRecordPDF(patientName, diagnosis, prescription, careSuggestion)
-	if err != nil {
-		return err
+func IsSupportedLocale(value string) bool {
+	for _, locale := range SupportedLocales {
+		if value == locale {
+			return true
+		}
 	}
+	return false
+}
 
-	smtpServer := os.Getenv("SMTP_SERVER")
-	smtpPortStr := os.Getenv("SMTP_PORT")
-	smtpUsername := os.Getenv("SMTP_USERNAME")
-	smtpPassword := os.Getenv("SMTP_PASSWORD")
+// RenderEmail merender subject, isi HTML dan isi teks biasa dari template jenis email dalam bahasa yang diminta
+func RenderEmail(kind, locale string, data EmailTemplateData) (EmailMessage, error) {
+	data.Locale = NormalizeLocale(locale)
+	template, ok := emailTemplates[data.Locale+"/"+kind]
+	if !ok {
+		return EmailMessage{}, fmt.Errorf("%w %q", ErrUnknownEmailKind, kind)
+	}
 
-	sender := smtpUsername
-	recipient := patientEmail
-	subject := "Your Medical Record from health"
-	emailBody := `
-	<html>
-	<head>
-		<style>
-			/* Styles for email body */
-		</style>
-	</head>
-	<body>
-		<p>Hello, <strong>` + patientName + `</strong>,</p>
-		<p>Please find attached your medical record from health.</p>
-		<p>If you have any questions or need assistance, please contact us at health@gmail.com.</p>
-		<p>Regards,<br>health Team</p>
-	</body>
-	</html>
-	`
+	var subject, text, html bytes.Buffer
+	if err := template.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return EmailMessage{}, err
+	}
+	if err := template.text.ExecuteTemplate(&text, "body", data); err != nil {
+		return EmailMessage{}, err
+	}
+	if err := template.html.Execute(&html, data); err != nil {
+		return EmailMessage{}, err
+	}
 
-	m := gomail.NewMessage()
-	m.SetHeader("From", sender)
-	m.SetHeader("To", recipient)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", emailBody)
-	m.Attach("medical_record.pdf", gomail.SetCopyFunc(func(w io.Writer) error {
-		_, err := w.Write(pdfBytes)
-		return err
-	}))
+	return EmailMessage{
+		Subject:  strings.TrimSpace(subject.String()),
+		HTMLBody: html.String(),
+		TextBody: text.String(),
+	}, nil
+}
 
-	smtpPort, err := strconv.Atoi(smtpPortStr)
-	if err != nil {
-		return err
+// RenderTextMessage merender isi SMS atau WhatsApp dalam bahasa yang diminta
+func RenderTextMessage(kind, locale string, data EmailTemplateData) (string, error) {
+	data.Locale = NormalizeLocale(locale)
+	template, ok := textMessageTemplates[data.Locale+"/"+kind]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownMessageKind, kind)
 	}
 
-	d := gomail.NewDialer(smtpServer, smtpPort, smtpUsername, smtpPassword)
-	if err := d.DialAndSend(m); err != nil {
-		return err
+	var body bytes.Buffer
+	if err := template.Execute(&body, data); err != nil {
+		return "", err
 	}
+	return strings.TrimSpace(body.String()), nil
+}
 
-	return nil
+// SampleEmailData adalah data contoh untuk pratinjau template oleh admin
+func SampleEmailData(now time.Time) EmailTemplateData {
+	return EmailTemplateData{
+		Name:           "Siti Rahayu",
+		Link:           VerificationLink("preview-token"),
+		LinkExpiresAt:  now.Add(appconfig.Get().PortalLinkTTL),
+		LockedUntil:    now.Add(15 * time.Minute),
+		DoctorName:     "dr. Andi Pratama",
+		ClinicName:     "Klinik Sehat Sentosa",
+		AppointmentAt:  now.Add(24 * time.Hour),
+		VisitAt:        now,
+		Diagnosis:      "J06.9 Acute upper respiratory infection",
+		Prescriptions:  []string{"Paracetamol 500 mg, 3x1, oral, 5 days (qty 15)", "Ambroxol 30 mg, 3x1, oral, 5 days (qty 15)"},
+		CareSuggestion: "Rest and drink plenty of water",
+	}
 }
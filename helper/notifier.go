@@ -0,0 +1,207 @@
+package helper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/go-gomail/gomail"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Backend notifier yang bisa dipilih lewat NOTIFIER
+const (
+	NotifierSMTP   = "smtp"
+	NotifierFile   = "file"
+	NotifierMemory = "memory"
+)
+
+// DefaultNotifierFrom dipakai sebagai alamat pengirim jika SMTP_FROM dan SMTP_USERNAME kosong
+const DefaultNotifierFrom = "no-reply@health.local"
+
+// EmailAttachment adalah lampiran email, misalnya PDF rekam medis
+type EmailAttachment struct {
+	Filename string
+	Content  []byte
+}
+
+// EmailMessage adalah email yang sudah dirender dan siap dikirim oleh Notifier. TextBody dikirim sebagai alternatif
+// teks biasa untuk mail client yang tidak menampilkan HTML.
+type EmailMessage struct {
+	To          string
+	Subject     string
+	HTMLBody    string
+	TextBody    string
+	Attachments []EmailAttachment
+}
+
+// Notifier mengirim email. Implementasinya dipilih saat aplikasi dijalankan dan diteruskan ke worker outbox.
+type Notifier interface {
+	Send(message EmailMessage) error
+}
+
+/*
+NewNotifierFromEnv memilih backend notifier dari NOTIFIER:
+smtp (default) -> kirim lewat SMTP_SERVER, SMTP_PORT, SMTP_USERNAME dan SMTP_PASSWORD
+file -> tulis email ke maildir di NOTIFIER_DIR (default ./mail) untuk development lokal
+memory -> simpan email di memori, untuk pengujian
+*/
+func NewNotifierFromEnv() (Notifier, error) {
+	switch backend := os.Getenv("NOTIFIER"); backend {
+	case "", NotifierSMTP:
+		return NewSMTPNotifierFromEnv()
+	case NotifierFile:
+		dir := os.Getenv("NOTIFIER_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		return NewFileNotifier(dir, notifierFromAddress())
+	case NotifierMemory:
+		return NewMemoryNotifier(), nil
+	default:
+		return nil, fmt.Errorf("unknown NOTIFIER backend %q", backend)
+	}
+}
+
+func notifierFromAddress() string {
+	if from := os.Getenv("SMTP_FROM"); from != "" {
+		return from
+	}
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		return username
+	}
+	return DefaultNotifierFrom
+}
+
+func buildMailMessage(from string, message EmailMessage) *gomail.Message {
+	m := gomail.NewMessage()
+	m.SetHeader("From", from)
+	m.SetHeader("To", message.To)
+	m.SetHeader("Subject", message.Subject)
+	if message.TextBody != "" {
+		m.SetBody("text/plain", message.TextBody)
+		m.AddAlternative("text/html", message.HTMLBody)
+	} else {
+		m.SetBody("text/html", message.HTMLBody)
+	}
+	for _, attachment := range message.Attachments {
+		content := attachment.Content
+		m.Attach(attachment.Filename, gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := w.Write(content)
+			return err
+		}))
+	}
+	return m
+}
+
+// SMTPNotifier mengirim email lewat server SMTP
+type SMTPNotifier struct {
+	From   string
+	dialer *gomail.Dialer
+}
+
+func NewSMTPNotifier(host string, port int, username, password, from string) *SMTPNotifier {
+	return &SMTPNotifier{
+		From:   from,
+		dialer: gomail.NewDialer(host, port, username, password),
+	}
+}
+
+// NewSMTPNotifierFromEnv membaca konfigurasi SMTP sekali saat aplikasi dijalankan
+func NewSMTPNotifierFromEnv() (*SMTPNotifier, error) {
+	host := os.Getenv("SMTP_SERVER")
+	if host == "" {
+		return nil, errors.New("SMTP_SERVER is not set")
+	}
+	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP_PORT: %w", err)
+	}
+	return NewSMTPNotifier(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), notifierFromAddress()), nil
+}
+
+func (n *SMTPNotifier) Send(message EmailMessage) error {
+	return n.dialer.DialAndSend(buildMailMessage(n.From, message))
+}
+
+/*
+FileNotifier menulis setiap email sebagai file .eml ke dalam maildir (tmp, new, cur) sehingga bisa dibuka dengan
+mail client atau dibaca langsung saat development tanpa server SMTP.
+*/
+type FileNotifier struct {
+	Dir      string
+	From     string
+	sequence atomic.Uint64
+}
+
+func NewFileNotifier(dir, from string) (*FileNotifier, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &FileNotifier{Dir: dir, From: from}, nil
+}
+
+func (n *FileNotifier) Send(message EmailMessage) error {
+	var buffer bytes.Buffer
+	if _, err := buildMailMessage(n.From, message).WriteTo(&buffer); err != nil {
+		return err
+	}
+
+	// Email ditulis ke tmp lalu dipindah ke new supaya pembaca maildir tidak pernah melihat file setengah jadi
+	name := fmt.Sprintf("%d.%d_%d.eml", time.Now().UnixNano(), os.Getpid(), n.sequence.Add(1))
+	tmpPath := filepath.Join(n.Dir, "tmp", name)
+	if err := os.WriteFile(tmpPath, buffer.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filepath.Join(n.Dir, "new", name))
+}
+
+// MemoryNotifier menyimpan email yang dikirim di memori supaya pengujian bisa memeriksa isinya
+type MemoryNotifier struct {
+	mu       sync.Mutex
+	messages []EmailMessage
+}
+
+func NewMemoryNotifier() *MemoryNotifier {
+	return &MemoryNotifier{}
+}
+
+func (n *MemoryNotifier) Send(message EmailMessage) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, message)
+	return nil
+}
+
+// Messages mengembalikan salinan email yang sudah dikirim, urut sesuai waktu pengiriman
+func (n *MemoryNotifier) Messages() []EmailMessage {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]EmailMessage(nil), n.messages...)
+}
+
+// MessagesTo mengembalikan email yang dikirim ke alamat tertentu
+func (n *MemoryNotifier) MessagesTo(recipient string) []EmailMessage {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var found []EmailMessage
+	for _, message := range n.messages {
+		if message.To == recipient {
+			found = append(found, message)
+		}
+	}
+	return found
+}
+
+func (n *MemoryNotifier) Reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = nil
+}
@@ -0,0 +1,69 @@
+package helper
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"medis/models"
+	"strings"
+)
+
+var ErrNotOrganizationMember = errors.New("You are not a member of this organization")
+
+// CreatePersonalOrganization membuat praktik pribadi untuk dokter yang belum tergabung di klinik mana pun
+func CreatePersonalOrganization(db *gorm.DB, doctor *models.Doctor) (*models.Organization, error) {
+	name := strings.TrimSpace(doctor.Fullname)
+	if name == "" {
+		name = doctor.Username
+	}
+
+	organization := models.Organization{
+		Name:        name + " Practice",
+		IsPersonal:  true,
+		CreatedByID: doctor.ID,
+	}
+	if err := db.Create(&organization).Error; err != nil {
+		return nil, err
+	}
+
+	membership := models.Membership{
+		OrganizationID: organization.ID,
+		DoctorID:       doctor.ID,
+		Role:           models.MembershipRoleOwner,
+	}
+	if err := db.Create(&membership).Error; err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
+
+func GetMembership(db *gorm.DB, organizationID, doctorID uint) (*models.Membership, error) {
+	var membership models.Membership
+	err := db.Where("organization_id = ? AND doctor_id = ?", organizationID, doctorID).First(&membership).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotOrganizationMember
+		}
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// ResolveOrganizationID memilih klinik aktif saat login. Jika klinik diminta, dokter harus menjadi anggotanya;
+// jika tidak, klinik pertama tempat dokter bergabung yang dipakai.
+func ResolveOrganizationID(db *gorm.DB, doctorID, requestedID uint) (uint, error) {
+	if requestedID != 0 {
+		if _, err := GetMembership(db, requestedID, doctorID); err != nil {
+			return 0, err
+		}
+		return requestedID, nil
+	}
+
+	var membership models.Membership
+	if err := db.Where("doctor_id = ?", doctorID).Order("id ASC").First(&membership).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return membership.OrganizationID, nil
+}
@@ -0,0 +1,461 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"medis/models"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pengaturan worker outbox. Jeda percobaan ulang berlipat dua mulai dari outboxBaseBackoff sampai outboxMaxBackoff.
+const (
+	DefaultOutboxMaxAttempts  = 8
+	DefaultOutboxPollInterval = 5 * time.Second
+	outboxBatchSize           = 20
+	outboxBaseBackoff         = 30 * time.Second
+	outboxMaxBackoff          = time.Hour
+	outboxLease               = 5 * time.Minute
+	outboxLastErrorMaxLength  = 1000
+)
+
+/*
+EmailPayload adalah data template email. Field yang tidak dipakai oleh jenis email tertentu dibiarkan kosong. Payload
+tidak pernah memuat token: link verifikasi dan reset password dibuat dari DoctorID saat email dikirim.
+*/
+type EmailPayload struct {
+	Name            string     `json:"name,omitempty"`
+	DoctorID        uint       `json:"doctor_id,omitempty"`
+	LockedUntil     *time.Time `json:"locked_until,omitempty"`
+	MedicalRecordID uint       `json:"medical_record_id,omitempty"`
+	AppointmentID   uint       `json:"appointment_id,omitempty"`
+	Locale          string     `json:"locale,omitempty"`
+}
+
+// EnqueueEmail menulis email ke outbox. Panggil dengan tx yang sama dengan data yang memicu email tersebut.
+func EnqueueEmail(tx *gorm.DB, kind, recipient string, payload EmailPayload) error {
+	return enqueueOutbox(tx, kind, models.ChannelEmail, recipient, payload, time.Now())
+}
+
+/*
+EnqueuePatientNotification mengantrekan notifikasi ke pasien lewat kanal pilihannya (lihat PatientContact) untuk
+dikirim mulai sendAt. Mengembalikan false tanpa error jika pasien tidak memiliki email maupun nomor telepon yang valid.
+*/
+func EnqueuePatientNotification(tx *gorm.DB, kind string, patient *models.Patient, payload EmailPayload, sendAt time.Time) (bool, error) {
+	channel, recipient, ok := PatientContact(patient)
+	if !ok {
+		return false, nil
+	}
+	if payload.Locale == "" {
+		payload.Locale = patient.Language
+	}
+	return true, enqueueOutbox(tx, kind, channel, recipient, payload, sendAt)
+}
+
+func enqueueOutbox(tx *gorm.DB, kind, channel, recipient string, payload EmailPayload, sendAt time.Time) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.OutboxEmail{
+		Kind:          kind,
+		Channel:       channel,
+		Recipient:     recipient,
+		Payload:       string(data),
+		Status:        models.EmailStatusPending,
+		NextAttemptAt: sendAt,
+	}).Error
+}
+
+// OutboxBackoff menghitung jeda sebelum percobaan berikutnya setelah attempts kali gagal
+func OutboxBackoff(attempts int) time.Duration {
+	backoff := outboxBaseBackoff
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > outboxMaxBackoff {
+		backoff = outboxMaxBackoff
+	}
+	return backoff
+}
+
+/*
+RunOutboxWorker mengirim email dari outbox sampai ctx dibatalkan. Beberapa instance boleh berjalan bersamaan karena
+email diambil dengan FOR UPDATE SKIP LOCKED dan langsung diberi lease, sehingga satu email tidak dikirim dua worker.
+Interval polling diatur lewat OUTBOX_POLL_INTERVAL (detik) dan batas percobaan lewat OUTBOX_MAX_ATTEMPTS.
+Email dikirim lewat notifier dan SMS/WhatsApp lewat messenger yang diberikan, sehingga backend-nya bisa dipilih dari
+luar. messenger boleh nil jika pengiriman SMS dan WhatsApp belum dikonfigurasi.
+*/
+func RunOutboxWorker(ctx context.Context, db *gorm.DB, notifier Notifier, messenger MessagingProvider) {
+	interval := DefaultOutboxPollInterval
+	if seconds, err := strconv.Atoi(os.Getenv("OUTBOX_POLL_INTERVAL")); err == nil && seconds > 0 {
+		interval = time.Duration(seconds) * time.Second
+	}
+	maxAttempts := OutboxMaxAttempts()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		// Selama masih ada email jatuh tempo, batch berikutnya langsung diproses tanpa menunggu tick
+		for ctx.Err() == nil {
+			processed, err := ProcessOutboxBatch(db, notifier, messenger, maxAttempts, time.Now())
+			if err != nil {
+				log.Println("Failed to process email outbox:", err)
+				break
+			}
+			if processed < outboxBatchSize {
+				break
+			}
+		}
+	}
+}
+
+// OutboxMaxAttempts membaca batas percobaan pengiriman dari OUTBOX_MAX_ATTEMPTS
+func OutboxMaxAttempts() int {
+	if attempts, err := strconv.Atoi(os.Getenv("OUTBOX_MAX_ATTEMPTS")); err == nil && attempts > 0 {
+		return attempts
+	}
+	return DefaultOutboxMaxAttempts
+}
+
+// ProcessOutboxBatch mengambil email dan pesan yang sudah jatuh tempo lalu mengirimnya satu per satu.
+// Pengujian bisa memanggilnya langsung untuk mengosongkan outbox tanpa menunggu worker.
+func ProcessOutboxBatch(db *gorm.DB, notifier Notifier, messenger MessagingProvider, maxAttempts int, now time.Time) (int, error) {
+	emails, err := claimOutboxEmails(db, now, func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("status = ? AND next_attempt_at <= ?", models.EmailStatusPending, now).
+			Order("next_attempt_at ASC").Limit(outboxBatchSize)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, email := range emails {
+		sendErr := deliverOutboxEmail(db, notifier, messenger, email)
+		if err := recordOutboxResult(db, email, sendErr, maxAttempts, time.Now()); err != nil {
+			return len(emails), err
+		}
+	}
+	return len(emails), nil
+}
+
+/*
+SendOutboxEmail langsung mengirim satu email pending tanpa menunggu worker, misalnya saat admin mengirim ulang email
+dead letter. Email yang sedang dipegang worker lain dilewati. Mengembalikan baris outbox setelah pengiriman, sehingga
+status dan last_error-nya bisa langsung ditampilkan.
+*/
+func SendOutboxEmail(db *gorm.DB, notifier Notifier, messenger MessagingProvider, id uint, maxAttempts int, now time.Time) (models.OutboxEmail, error) {
+	emails, err := claimOutboxEmails(db, now, func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("id = ? AND status = ?", id, models.EmailStatusPending)
+	})
+	if err != nil {
+		return models.OutboxEmail{}, err
+	}
+	for _, email := range emails {
+		sendErr := deliverOutboxEmail(db, notifier, messenger, email)
+		if err := recordOutboxResult(db, email, sendErr, maxAttempts, time.Now()); err != nil {
+			return email, err
+		}
+	}
+
+	var email models.OutboxEmail
+	err = db.First(&email, id).Error
+	return email, err
+}
+
+// claimOutboxEmails mengunci email yang dipilih scope lalu memberinya lease dan menambah jumlah percobaannya
+func claimOutboxEmails(db *gorm.DB, now time.Time, scope func(*gorm.DB) *gorm.DB) ([]models.OutboxEmail, error) {
+	var emails []models.OutboxEmail
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(scope).Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).Find(&emails).Error; err != nil {
+			return err
+		}
+		if len(emails) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(emails))
+		for i, email := range emails {
+			ids[i] = email.ID
+		}
+		// Lease mencegah worker lain mengambil email yang sedang dikirim; jika worker ini mati, email diambil lagi setelah lease habis
+		return tx.Model(&models.OutboxEmail{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": now.Add(outboxLease),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range emails {
+		emails[i].Attempts++
+	}
+	return emails, nil
+}
+
+func recordOutboxResult(db *gorm.DB, email models.OutboxEmail, sendErr error, maxAttempts int, now time.Time) error {
+	if sendErr == nil {
+		return db.Model(&email).Updates(map[string]interface{}{
+			"status":     models.EmailStatusSent,
+			"sent_at":    now,
+			"payload":    "{}",
+			"last_error": "",
+		}).Error
+	}
+
+	message := sendErr.Error()
+	if len(message) > outboxLastErrorMaxLength {
+		message = message[:outboxLastErrorMaxLength]
+	}
+
+	var permanent *permanentDeliveryError
+	if email.Attempts >= maxAttempts || errors.As(sendErr, &permanent) {
+		log.Printf("Email outbox %d (%s) moved to dead letter after %d attempts: %s", email.ID, email.Kind, email.Attempts, message)
+		return db.Model(&email).Updates(map[string]interface{}{
+			"status":     models.EmailStatusDead,
+			"last_error": message,
+		}).Error
+	}
+
+	return db.Model(&email).Updates(map[string]interface{}{
+		"next_attempt_at": now.Add(OutboxBackoff(email.Attempts)),
+		"last_error":      message,
+	}).Error
+}
+
+// permanentDeliveryError menandai kegagalan yang tidak akan berhasil jika dicoba lagi, misalnya rekam medis sudah dihapus
+type permanentDeliveryError struct {
+	message string
+}
+
+func (e *permanentDeliveryError) Error() string {
+	return e.message
+}
+
+func deliverOutboxEmail(db *gorm.DB, notifier Notifier, messenger MessagingProvider, email models.OutboxEmail) error {
+	var payload EmailPayload
+	if err := json.Unmarshal([]byte(email.Payload), &payload); err != nil {
+		return &permanentDeliveryError{message: fmt.Sprintf("invalid payload: %v", err)}
+	}
+
+	if IsMessagingChannel(email.Channel) && (messenger == nil || !e164Pattern.MatchString(email.Recipient)) {
+		if err := outboxEmailFallback(db, &email, payload); err != nil {
+			return err
+		}
+	}
+
+	data, attachments, err := outboxTemplateData(db, email, payload)
+	if err != nil {
+		return err
+	}
+
+	// Template yang gagal dirender tidak akan berhasil jika dicoba lagi
+	if IsMessagingChannel(email.Channel) {
+		body, err := RenderTextMessage(email.Kind, payload.Locale, data)
+		if err != nil {
+			return &permanentDeliveryError{message: err.Error()}
+		}
+		return messenger.SendMessage(TextMessage{Channel: email.Channel, To: email.Recipient, Body: body})
+	}
+
+	message, err := RenderEmail(email.Kind, payload.Locale, data)
+	if err != nil {
+		return &permanentDeliveryError{message: err.Error()}
+	}
+	message.To = email.Recipient
+	message.Attachments = attachments
+	return notifier.Send(message)
+}
+
+/*
+outboxEmailFallback mengalihkan SMS/WhatsApp pasien ke email jika penyedia pesan belum dikonfigurasi atau nomor
+tujuannya tidak valid, supaya pasien tetap menerima notifikasinya. Pasien dicari lewat rekam medis atau janji temu
+di payload, lalu kanal dan tujuan baris outbox ikut diperbarui.
+*/
+func outboxEmailFallback(db *gorm.DB, email *models.OutboxEmail, payload EmailPayload) error {
+	var patientID uint
+	var err error
+	switch email.Kind {
+	case models.EmailKindMedicalRecord:
+		var medicalRecord models.MedicalRecords
+		err = db.Select("id", "patient_id").First(&medicalRecord, payload.MedicalRecordID).Error
+		patientID = medicalRecord.PatientID
+	case models.EmailKindAppointmentReminder:
+		var appointment models.Appointment
+		err = db.Select("id", "patient_id").First(&appointment, payload.AppointmentID).Error
+		patientID = appointment.PatientID
+	}
+	var patient models.Patient
+	if err == nil && patientID != 0 {
+		err = db.First(&patient, patientID).Error
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if patient.Email == "" {
+		return &permanentDeliveryError{message: "messaging is not available and the patient has no email"}
+	}
+
+	email.Channel = models.ChannelEmail
+	email.Recipient = patient.Email
+	return db.Model(&models.OutboxEmail{}).Where("id = ?", email.ID).Updates(map[string]interface{}{
+		"channel":   email.Channel,
+		"recipient": email.Recipient,
+	}).Error
+}
+
+// outboxTemplateData menyiapkan isi template. Lampiran PDF rekam medis hanya dibuat untuk kanal email.
+func outboxTemplateData(db *gorm.DB, email models.OutboxEmail, payload EmailPayload) (EmailTemplateData, []EmailAttachment, error) {
+	data := EmailTemplateData{Name: payload.Name}
+	var attachments []EmailAttachment
+
+	switch email.Kind {
+	case models.EmailKindWelcome, models.EmailKindEmailChange, models.EmailKindPasswordReset:
+		link, err := outboxAccountLink(db, email, payload, time.Now())
+		if err != nil {
+			return data, nil, err
+		}
+		data.Link = link
+	case models.EmailKindAccountLockout:
+		if payload.LockedUntil == nil {
+			return data, nil, &permanentDeliveryError{message: "lockout email without locked_until"}
+		}
+		data.LockedUntil = *payload.LockedUntil
+	case models.EmailKindMedicalRecord:
+		// Rekam medis dibaca saat email dikirim supaya PDF sama dengan data yang tersimpan
+		var medicalRecord models.MedicalRecords
+		err := db.Preload("SecondaryICD10").Preload("PrescriptionItems").First(&medicalRecord, payload.MedicalRecordID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return data, nil, &permanentDeliveryError{message: "medical record no longer exists"}
+		}
+		if err != nil {
+			return data, nil, err
+		}
+		data.Name = medicalRecord.PatientName
+		data.DoctorName = outboxDoctorName(db, medicalRecord.DoctorID)
+		if medicalRecord.CreatedAt != nil {
+			data.VisitAt = *medicalRecord.CreatedAt
+		}
+		data.Diagnosis = medicalRecord.Diagnosis
+		if data.Diagnosis == "" {
+			data.Diagnosis = medicalRecord.PrimaryICD10
+		}
+		for _, item := range medicalRecord.PrescriptionItems {
+			data.Prescriptions = append(data.Prescriptions, FormatPrescriptionItem(item))
+		}
+		if len(data.Prescriptions) == 0 && medicalRecord.Prescription != "" {
+			data.Prescriptions = []string{medicalRecord.Prescription}
+		}
+		data.CareSuggestion = medicalRecord.CareSuggestion
+		data.Link, data.LinkExpiresAt = MedicalRecordPortalLink(medicalRecord.ID, time.Now())
+
+		if !IsMessagingChannel(email.Channel) {
+			pdfBytes, err := GenerateMedicalRecordPDF(medicalRecord)
+			if err != nil {
+				return data, nil, err
+			}
+			attachments = []EmailAttachment{{Filename: "medical_record.pdf", Content: pdfBytes}}
+		}
+	case models.EmailKindAppointmentReminder:
+		var appointment models.Appointment
+		err := db.First(&appointment, payload.AppointmentID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return data, nil, &permanentDeliveryError{message: "appointment no longer exists"}
+		}
+		if err != nil {
+			return data, nil, err
+		}
+		if appointment.Status != models.AppointmentStatusScheduled {
+			return data, nil, &permanentDeliveryError{message: "appointment is no longer scheduled"}
+		}
+		data.AppointmentAt = appointment.StartsAt
+		data.DoctorName = outboxDoctorName(db, appointment.DoctorID)
+		var organization models.Organization
+		if err := db.Select("id", "name").First(&organization, appointment.OrganizationID).Error; err == nil {
+			data.ClinicName = organization.Name
+		}
+	}
+	return data, attachments, nil
+}
+
+/*
+outboxAccountLink membuat link verifikasi atau reset password saat email dikirim. Token verifikasi yang sudah
+kedaluwarsa dibuat ulang dan token reset password selalu dibuat baru, sehingga email yang dikirim ulang dari dead
+letter tidak pernah berisi link mati. Email dibatalkan jika tujuannya sudah tidak sesuai dengan data dokter, misalnya
+email sudah diverifikasi atau alamatnya sudah diganti.
+*/
+func outboxAccountLink(db *gorm.DB, email models.OutboxEmail, payload EmailPayload, now time.Time) (string, error) {
+	var doctor models.Doctor
+	query := db.Where("id = ?", payload.DoctorID)
+	if payload.DoctorID == 0 {
+		// Baris outbox lama belum menyimpan doctor_id
+		query = db.Where("email = ? OR pending_email = ?", email.Recipient, email.Recipient)
+	}
+	err := query.First(&doctor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", &permanentDeliveryError{message: "doctor no longer exists"}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	switch email.Kind {
+	case models.EmailKindPasswordReset:
+		if doctor.Email != email.Recipient {
+			return "", &permanentDeliveryError{message: "email address changed after the password reset request"}
+		}
+		resetToken := GenerateUniqueToken()
+		err := db.Model(&doctor).Updates(map[string]interface{}{
+			"password_reset_token_hash": HashToken(resetToken),
+			"password_reset_expires_at": now.Add(PasswordResetTokenDuration),
+		}).Error
+		if err != nil {
+			return "", err
+		}
+		return PasswordResetLink(resetToken), nil
+	case models.EmailKindEmailChange:
+		if doctor.PendingEmail != email.Recipient {
+			return "", &permanentDeliveryError{message: "email change is no longer pending"}
+		}
+	default:
+		if doctor.Email != email.Recipient {
+			return "", &permanentDeliveryError{message: "email address changed after registration"}
+		}
+		if doctor.IsVerified {
+			return "", &permanentDeliveryError{message: "email is already verified"}
+		}
+	}
+
+	if doctor.VerificationToken == "" || doctor.VerificationExpiresAt == nil || !now.Before(*doctor.VerificationExpiresAt) {
+		NewVerificationToken(&doctor)
+		err := db.Model(&doctor).Updates(map[string]interface{}{
+			"verification_token":      doctor.VerificationToken,
+			"verification_expires_at": doctor.VerificationExpiresAt,
+			"verification_sent_at":    doctor.VerificationSentAt,
+			"verification_used_at":    nil,
+		}).Error
+		if err != nil {
+			return "", err
+		}
+	}
+	return VerificationLink(doctor.VerificationToken), nil
+}
+
+func outboxDoctorName(db *gorm.DB, doctorID uint) string {
+	var doctor models.Doctor
+	if err := db.Select("id", "first_name", "last_name").First(&doctor, doctorID).Error; err != nil {
+		return ""
+	}
+	return strings.TrimSpace("dr. " + doctor.FirstName + " " + doctor.LastName)
+}
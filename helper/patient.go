@@ -0,0 +1,66 @@
+package helper
+
+import (
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"medis/models"
+	"net/http"
+	"strings"
+)
+
+// Pesan konflik NIK dan nomor BPJS, dipakai baik oleh pengecekan sebelum menyimpan maupun oleh unique index
+const (
+	PatientNIKConflictMessage  = "NIK already registered to another patient"
+	PatientBPJSConflictMessage = "BPJS number already registered to another patient"
+)
+
+const uniqueViolationCode = "23505"
+
+// FindMatchingPatient mencari pasien di klinik yang sama dengan nama, tanggal lahir dan kontak yang sama.
+// Pencocokan nama dan email tidak membedakan huruf besar/kecil. Mengembalikan nil jika belum ada.
+func FindMatchingPatient(db *gorm.DB, organizationID uint, name, birthDate, email, phoneNumber string) (*models.Patient, error) {
+	var patients []models.Patient
+	err := db.Where("organization_id = ?", organizationID).
+		Where("LOWER(TRIM(name)) = ? AND birth_date = ?", strings.ToLower(strings.TrimSpace(name)), birthDate).
+		Where("LOWER(TRIM(email)) = ? AND TRIM(phone_number) = ?", strings.ToLower(strings.TrimSpace(email)), strings.TrimSpace(phoneNumber)).
+		Order("id ASC").
+		Limit(1).
+		Find(&patients).Error
+	if err != nil || len(patients) == 0 {
+		return nil, err
+	}
+	return &patients[0], nil
+}
+
+// SyncPatientRecords menyalin identitas pasien ke semua rekam medisnya supaya kolom lama pada
+// medical_records tetap konsisten untuk klien yang belum memakai patient_id
+func SyncPatientRecords(db *gorm.DB, patient *models.Patient) error {
+	return db.Model(&models.MedicalRecords{}).
+		Where("patient_id = ?", patient.ID).
+		Updates(map[string]interface{}{
+			"patient_name": patient.Name,
+			"birth_date":   patient.BirthDate,
+			"email":        patient.Email,
+			"phone_number": patient.PhoneNumber,
+		}).Error
+}
+
+/*
+PatientIdentifierConflict mengenali penolakan dari unique index NIK dan nomor BPJS. Pengecekan sebelum menyimpan bisa
+kalah balapan dengan request lain yang mendaftarkan pasien yang sama, sehingga error dari database dipetakan ke
+response 409 yang sama. Mengembalikan nil untuk error lain.
+*/
+func PatientIdentifierConflict(err error) *ErrorResponse {
+	var pgError *pgconn.PgError
+	if !errors.As(err, &pgError) || pgError.Code != uniqueViolationCode {
+		return nil
+	}
+	switch pgError.ConstraintName {
+	case "idx_patient_organization_nik":
+		return &ErrorResponse{Code: http.StatusConflict, Message: PatientNIKConflictMessage}
+	case "idx_patient_organization_bpjs":
+		return &ErrorResponse{Code: http.StatusConflict, Message: PatientBPJSConflictMessage}
+	}
+	return nil
+}
@@ -2,10 +2,26 @@ package helper
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/jung-kurt/gofpdf"
+	"medis/models"
 )
 
-func GenerateMedicalRecordPDF(patientName, diagnosis, prescription, careSuggestion string) ([]byte, error) {
+// Lebar kolom tabel resep dalam mm, totalnya 190 mm (lebar A4 dikurangi margin)
+var prescriptionColumns = []struct {
+	Title string
+	Width float64
+}{
+	{"No", 8},
+	{"Product (KFA)", 70},
+	{"Dose", 22},
+	{"Frequency", 24},
+	{"Route", 22},
+	{"Duration", 22},
+	{"Qty", 22},
+}
+
+func GenerateMedicalRecordPDF(medicalRecord models.MedicalRecords) ([]byte, error) {
 	pdf := gofpdf.New("P", "mm", "A4", "")
 	pdf.AddPage()
 
@@ -26,16 +42,35 @@ func GenerateMedicalRecordPDF(patientName, diagnosis, prescription, careSuggesti
 
 	pdf.SetFont("Arial", "", 12)
 	pdf.CellFormat(40, 10, "Patient Name", "1", 0, "", false, 0, "")
-	pdf.CellFormat(0, 10, patientName, "1", 1, "", false, 0, "")
+	pdf.CellFormat(0, 10, medicalRecord.PatientName, "1", 1, "", false, 0, "")
 
-	pdf.CellFormat(40, 10, "Diagnosis", "1", 0, "", false, 0, "")
-	pdf.MultiCell(0, 10, diagnosis, "1", "L", false)
+	// Rekam medis dengan format SOAP ditampilkan per bagian. Diagnosis dan care suggestion hanya ditampilkan
+	// lagi jika isinya berbeda dari Assessment dan Plan.
+	soap := medicalRecord.SOAP
+	if !soap.IsEmpty() {
+		writeDetailRow(pdf, "Subjective", soap.Subjective)
+		writeDetailRow(pdf, "Objective", soap.Objective)
+		writeDetailRow(pdf, "Assessment", soap.Assessment)
+	}
+	if soap.IsEmpty() || medicalRecord.Diagnosis != soap.Assessment {
+		writeDetailRow(pdf, "Diagnosis", medicalRecord.Diagnosis)
+	}
+	if !soap.IsEmpty() {
+		writeDetailRow(pdf, "Plan", soap.Plan)
+	}
+
+	// Jika resep terstruktur tersedia, daftar obat ditampilkan sebagai tabel terpisah di bawah
+	if len(medicalRecord.PrescriptionItems) == 0 {
+		writeDetailRow(pdf, "Prescription", medicalRecord.Prescription)
+	}
 
-	pdf.CellFormat(40, 10, "Prescription", "1", 0, "", false, 0, "")
-	pdf.MultiCell(0, 10, prescription, "1", "L", false)
+	if soap.IsEmpty() || medicalRecord.CareSuggestion != soap.Plan {
+		writeDetailRow(pdf, "Care Suggestion", medicalRecord.CareSuggestion)
+	}
 
-	pdf.CellFormat(40, 10, "Care Suggestion", "1", 0, "", false, 0, "")
-	pdf.MultiCell(0, 10, careSuggestion, "1", "L", false)
+	if len(medicalRecord.PrescriptionItems) > 0 {
+		writePrescriptionTable(pdf, medicalRecord.PrescriptionItems)
+	}
 
 	var buf bytes.Buffer
 	if err := pdf.Output(&buf); err != nil {
@@ -44,3 +79,67 @@ func GenerateMedicalRecordPDF(patientName, diagnosis, prescription, careSuggesti
 
 	return buf.Bytes(), nil
 }
+
+func writeDetailRow(pdf *gofpdf.Fpdf, label, value string) {
+	if value == "" {
+		value = "-"
+	}
+	pdf.CellFormat(40, 10, label, "1", 0, "", false, 0, "")
+	pdf.MultiCell(0, 10, value, "1", "L", false)
+}
+
+func writePrescriptionTable(pdf *gofpdf.Fpdf, items []models.PrescriptionItem) {
+	pdf.Ln(10)
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(0, 10, "Prescription", "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Arial", "B", 10)
+	for _, column := range prescriptionColumns {
+		pdf.CellFormat(column.Width, 8, column.Title, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 10)
+	for i, item := range items {
+		writePrescriptionRow(pdf, []string{
+			fmt.Sprint(i + 1),
+			item.ProductName + "\n" + item.KFACode,
+			FormatNumber(item.Dose) + " " + item.Unit,
+			item.Frequency,
+			item.Route,
+			fmt.Sprintf("%d days", item.DurationDays),
+			FormatNumber(item.Quantity),
+		})
+	}
+}
+
+// writePrescriptionRow menulis satu baris tabel. Teks panjang dibungkus ke beberapa baris dan
+// semua sel pada baris yang sama dibuat setinggi sel tertinggi.
+func writePrescriptionRow(pdf *gofpdf.Fpdf, cells []string) {
+	const lineHeight = 5.0
+
+	maxLines := 1
+	for i, text := range cells {
+		lines := pdf.SplitLines([]byte(text), prescriptionColumns[i].Width-2)
+		if len(lines) > maxLines {
+			maxLines = len(lines)
+		}
+	}
+	rowHeight := float64(maxLines)*lineHeight + 2
+
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottomMargin := pdf.GetMargins()
+	if pdf.GetY()+rowHeight > pageHeight-bottomMargin {
+		pdf.AddPage()
+	}
+
+	x, y := pdf.GetXY()
+	for i, text := range cells {
+		width := prescriptionColumns[i].Width
+		pdf.Rect(x, y, width, rowHeight, "D")
+		pdf.SetXY(x, y+1)
+		pdf.MultiCell(width, lineHeight, text, "", "L", false)
+		x += width
+	}
+	pdf.SetY(y + rowHeight)
+}
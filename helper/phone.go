@@ -0,0 +1,53 @@
+package helper
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Kode negara Indonesia. Nomor lokal (08xx) dan nomor tanpa awalan (8xx) dianggap nomor Indonesia.
+const indonesiaCountryCode = "62"
+
+var (
+	phoneSeparators     = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+	indonesianPhoneBody = regexp.MustCompile(`^[1-9]\d{7,11}$`)
+	e164Pattern         = regexp.MustCompile(`^\+[1-9]\d{7,14}$`)
+)
+
+var ErrInvalidPhoneNumber = errors.New("phone number cannot be converted to E.164")
+
+/*
+NormalizePhoneNumber mengubah nomor telepon ke format E.164 yang dipakai penyedia SMS dan WhatsApp.
+"0812-3456-7890", "6281234567890", "812 3456 7890" dan "+62 812 3456 7890" menjadi "+6281234567890".
+Nomor dengan kode negara lain harus diawali "+".
+*/
+func NormalizePhoneNumber(phone string) (string, error) {
+	phone = phoneSeparators.Replace(strings.TrimSpace(phone))
+
+	if strings.HasPrefix(phone, "+") {
+		if !e164Pattern.MatchString(phone) {
+			return "", ErrInvalidPhoneNumber
+		}
+		if national, ok := strings.CutPrefix(phone, "+"+indonesiaCountryCode); ok && !indonesianPhoneBody.MatchString(national) {
+			return "", ErrInvalidPhoneNumber
+		}
+		return phone, nil
+	}
+
+	var national string
+	switch {
+	case strings.HasPrefix(phone, "0"):
+		national = phone[1:]
+	case strings.HasPrefix(phone, indonesiaCountryCode):
+		national = phone[len(indonesiaCountryCode):]
+	case strings.HasPrefix(phone, "8"):
+		national = phone
+	default:
+		return "", ErrInvalidPhoneNumber
+	}
+	if !indonesianPhoneBody.MatchString(national) {
+		return "", ErrInvalidPhoneNumber
+	}
+	return "+" + indonesiaCountryCode + national, nil
+}
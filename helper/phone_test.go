@@ -0,0 +1,48 @@
+package helper
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizePhoneNumber(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"081234567890", "+6281234567890"},
+		{"0812-3456-7890", "+6281234567890"},
+		{"(0812) 3456.7890", "+6281234567890"},
+		{"6281234567890", "+6281234567890"},
+		{"62 812 3456 7890", "+6281234567890"},
+		{"+6281234567890", "+6281234567890"},
+		{"+62 812 3456 7890", "+6281234567890"},
+		{"81234567890", "+6281234567890"},
+		{"  0211234567  ", "+62211234567"},
+		{"+14155552671", "+14155552671"},
+	}
+	for _, test := range tests {
+		got, err := NormalizePhoneNumber(test.input)
+		if err != nil || got != test.want {
+			t.Errorf("NormalizePhoneNumber(%q) = (%q, %v), want %q", test.input, got, err, test.want)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"0812",
+		"08123456789012345",
+		"0012345678",
+		"620812345678",
+		"+620812345678",
+		"+62812",
+		"14155552671",
+		"+0123456789",
+		"0812abc67890",
+	}
+	for _, input := range invalid {
+		if got, err := NormalizePhoneNumber(input); !errors.Is(err, ErrInvalidPhoneNumber) {
+			t.Errorf("NormalizePhoneNumber(%q) = (%q, %v), want ErrInvalidPhoneNumber", input, got, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package helper
+
+import (
+	"fmt"
+	"medis/models"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Batas jumlah baris obat dalam satu resep
+const MaxPrescriptionItems = 30
+
+// Rute pemberian obat yang diterima, mengikuti istilah yang umum dipakai di resep
+var PrescriptionRoutes = map[string]bool{
+	"oral":          true,
+	"sublingual":    true,
+	"buccal":        true,
+	"topical":       true,
+	"transdermal":   true,
+	"inhalation":    true,
+	"nasal":         true,
+	"ophthalmic":    true,
+	"otic":          true,
+	"rectal":        true,
+	"vaginal":       true,
+	"intravenous":   true,
+	"intramuscular": true,
+	"subcutaneous":  true,
+}
+
+// Kode produk KFA terdiri dari 8 digit, contohnya 93001019
+var kfaCodePattern = regexp.MustCompile(`^\d{8}$`)
+
+func ValidateKFACode(code string) bool {
+	return kfaCodePattern.MatchString(code)
+}
+
+// ValidatePrescriptionItems merapikan dan memvalidasi setiap baris resep. Pesan error menyebutkan nomor baris.
+func ValidatePrescriptionItems(items []models.PrescriptionItem) ([]models.PrescriptionItem, error) {
+	if len(items) > MaxPrescriptionItems {
+		return nil, fmt.Errorf("A prescription can contain at most %d items", MaxPrescriptionItems)
+	}
+
+	normalized := make([]models.PrescriptionItem, 0, len(items))
+	for i, item := range items {
+		line := i + 1
+		item.ID = 0
+		item.MedicalRecordID = 0
+		item.KFACode = strings.TrimSpace(item.KFACode)
+		item.ProductName = strings.TrimSpace(item.ProductName)
+		item.Unit = strings.TrimSpace(item.Unit)
+		item.Frequency = strings.TrimSpace(item.Frequency)
+		item.Route = strings.ToLower(strings.TrimSpace(item.Route))
+
+		switch {
+		case !ValidateKFACode(item.KFACode):
+			return nil, fmt.Errorf("Prescription item %d: KFA code must be 8 digits", line)
+		case len(item.ProductName) < 1 || len(item.ProductName) > 200:
+			return nil, fmt.Errorf("Prescription item %d: product name must be between 1 and 200 characters", line)
+		case item.Dose <= 0:
+			return nil, fmt.Errorf("Prescription item %d: dose must be greater than 0", line)
+		case len(item.Unit) < 1 || len(item.Unit) > 20:
+			return nil, fmt.Errorf("Prescription item %d: unit must be between 1 and 20 characters", line)
+		case len(item.Frequency) < 1 || len(item.Frequency) > 50:
+			return nil, fmt.Errorf("Prescription item %d: frequency must be between 1 and 50 characters", line)
+		case !PrescriptionRoutes[item.Route]:
+			return nil, fmt.Errorf("Prescription item %d: route %q is not supported", line, item.Route)
+		case item.DurationDays < 1 || item.DurationDays > 365:
+			return nil, fmt.Errorf("Prescription item %d: duration must be between 1 and 365 days", line)
+		case item.Quantity <= 0:
+			return nil, fmt.Errorf("Prescription item %d: quantity must be greater than 0", line)
+		}
+		normalized = append(normalized, item)
+	}
+	return normalized, nil
+}
+
+// FormatPrescriptionItem menuliskan satu baris resep sebagai teks, misalnya "Paracetamol 500 mg, 3x1, oral, 5 days (qty 15)"
+func FormatPrescriptionItem(item models.PrescriptionItem) string {
+	return fmt.Sprintf("%s %s %s, %s, %s, %d days (qty %s)",
+		item.ProductName, FormatNumber(item.Dose), item.Unit, item.Frequency, item.Route, item.DurationDays, FormatNumber(item.Quantity))
+}
+
+// SummarizePrescription membuat teks resep dari baris-baris obat untuk kolom prescription yang lama
+func SummarizePrescription(items []models.PrescriptionItem) string {
+	lines := make([]string, 0, len(items))
+	for i, item := range items {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, FormatPrescriptionItem(item)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func FormatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,121 @@
+package helper
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"medis/models"
+	"sync"
+	"time"
+)
+
+// QueueDisplay adalah isi layar antrian di ruang tunggu. Sengaja tidak memuat data pasien.
+type QueueDisplay struct {
+	DoctorID      uint       `json:"doctor_id"`
+	DoctorName    string     `json:"doctor_name"`
+	QueueDate     string     `json:"queue_date"`
+	CurrentNumber int        `json:"current_number"`
+	CalledAt      *time.Time `json:"called_at"`
+	LastNumber    int        `json:"last_number"`
+	WaitingCount  int64      `json:"waiting_count"`
+}
+
+// QueueDate mengembalikan tanggal antrian (yyyy-mm-dd) menurut zona waktu klinik
+func QueueDate(now time.Time) string {
+	return now.In(ClinicLocation()).Format("2006-01-02")
+}
+
+// NextQueueNumber mengambil nomor antrian berikutnya secara atomik. Dipanggil di dalam transaksi yang sama
+// dengan pembuatan QueueEntry supaya nomor tidak terbuang jika check-in gagal.
+func NextQueueNumber(tx *gorm.DB, organizationID, doctorID uint, queueDate string) (int, error) {
+	var number int
+	err := tx.Raw(`
+		INSERT INTO queue_counters (organization_id, doctor_id, queue_date, last_number)
+		VALUES (?, ?, ?, 1)
+		ON CONFLICT (organization_id, doctor_id, queue_date)
+		DO UPDATE SET last_number = queue_counters.last_number + 1
+		RETURNING last_number`, organizationID, doctorID, queueDate).Scan(&number).Error
+	return number, err
+}
+
+// BuildQueueDisplay menghitung nomor yang sedang dipanggil, nomor terakhir yang dibagikan dan jumlah pasien menunggu
+func BuildQueueDisplay(db *gorm.DB, organizationID, doctorID uint, queueDate string) (QueueDisplay, error) {
+	display := QueueDisplay{DoctorID: doctorID, QueueDate: queueDate}
+
+	var doctor models.Doctor
+	if err := db.Select("id", "first_name", "last_name").First(&doctor, doctorID).Error; err != nil {
+		return display, err
+	}
+	display.DoctorName = doctor.FirstName + " " + doctor.LastName
+
+	scope := db.Model(&models.QueueEntry{}).Where("organization_id = ? AND doctor_id = ? AND queue_date = ?", organizationID, doctorID, queueDate)
+
+	var current models.QueueEntry
+	result := scope.Session(&gorm.Session{}).Where("called_at IS NOT NULL").Order("called_at DESC").Limit(1).Find(&current)
+	if result.Error != nil {
+		return display, result.Error
+	}
+	if result.RowsAffected > 0 {
+		display.CurrentNumber = current.Number
+		display.CalledAt = current.CalledAt
+	}
+
+	if err := scope.Session(&gorm.Session{}).Select("COALESCE(MAX(number), 0)").Scan(&display.LastNumber).Error; err != nil {
+		return display, err
+	}
+	if err := scope.Session(&gorm.Session{}).Where("status = ?", models.QueueStatusWaiting).Count(&display.WaitingCount).Error; err != nil {
+		return display, err
+	}
+	return display, nil
+}
+
+/*
+QueueBroker menyebarkan perubahan antrian ke layar ruang tunggu yang sedang terhubung lewat SSE. Broker hanya
+hidup di memori satu proses, jadi semua instance yang melayani layar antrian harus sama dengan yang menerima
+perubahan antrian.
+*/
+type QueueBroker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan QueueDisplay]struct{}
+}
+
+func NewQueueBroker() *QueueBroker {
+	return &QueueBroker{subscribers: map[string]map[chan QueueDisplay]struct{}{}}
+}
+
+func QueueKey(organizationID, doctorID uint, queueDate string) string {
+	return fmt.Sprintf("%d:%d:%s", organizationID, doctorID, queueDate)
+}
+
+// Subscribe mendaftarkan layar baru. Fungsi yang dikembalikan wajib dipanggil saat koneksi ditutup.
+func (b *QueueBroker) Subscribe(key string) (<-chan QueueDisplay, func()) {
+	ch := make(chan QueueDisplay, 4)
+
+	b.mu.Lock()
+	if b.subscribers[key] == nil {
+		b.subscribers[key] = map[chan QueueDisplay]struct{}{}
+	}
+	b.subscribers[key][ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subscribers[key], ch)
+		if len(b.subscribers[key]) == 0 {
+			delete(b.subscribers, key)
+		}
+		b.mu.Unlock()
+	}
+}
+
+// Publish tidak pernah menunggu layar yang lambat; jika buffer-nya penuh, pembaruan untuk layar itu dilewati
+// karena pembaruan berikutnya tetap membawa keadaan antrian yang lengkap.
+func (b *QueueBroker) Publish(key string, display QueueDisplay) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers[key] {
+		select {
+		case ch <- display:
+		default:
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package helper
+
+import (
+	"medis/models"
+	"time"
+)
+
+// Struktur untuk request registrasi dokter. Field seperti role dan status verifikasi sengaja tidak ada
+// agar tidak bisa diisi dari request.
+type DoctorRegistrationRequest struct {
+	FirstName     string `json:"first_name"`
+	LastName      string `json:"last_name"`
+	ContactNumber string `json:"contact_number"`
+	Gender        string `json:"gender"`
+	Email         string `json:"email"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	Language      string `json:"language"`
+	LicenseRequest
+}
+
+// Struktur data izin praktik dokter: STR, SIP, spesialisasi, dan tanggal berakhirnya izin (yyyy-mm-dd)
+type LicenseRequest struct {
+	STRNumber         string `json:"str_number"`
+	SIPNumber         string `json:"sip_number"`
+	Specialty         string `json:"specialty"`
+	LicenseExpiryDate string `json:"license_expiry_date"`
+}
+
+// Struktur untuk request login dokter
+type SignInRequest struct {
+	Username       string `json:"username"`
+	Password       string `json:"password"`
+	OrganizationID uint   `json:"organization_id"`
+}
+
+// Struktur untuk request refresh token
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Struktur untuk request logout, jika All bernilai true semua sesi milik dokter dicabut
+type SignOutRequest struct {
+	All bool `json:"all"`
+}
+
+// Struktur untuk request lupa password
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// Struktur untuk request reset password menggunakan token dari email
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// Struktur untuk request kirim ulang email verifikasi
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+// Struktur untuk langkah kedua login dengan kode TOTP atau recovery code
+type TwoFactorSignInRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+	OrganizationID uint   `json:"organization_id"`
+}
+
+// Struktur untuk mengaktifkan, menonaktifkan, dan membuat ulang recovery code 2FA
+type TwoFactorCodeRequest struct {
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// Struktur untuk request perubahan role oleh admin
+type UpdateRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// Struktur untuk request perubahan profil, field yang kosong tidak diubah
+type UpdateProfileRequest struct {
+	FirstName     string `json:"first_name"`
+	LastName      string `json:"last_name"`
+	ContactNumber string `json:"contact_number"`
+	Gender        string `json:"gender"`
+	Language      string `json:"language"`
+}
+
+// Struktur untuk request ganti password dari halaman profil
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// Struktur untuk request ganti email, email baru harus diverifikasi ulang
+type ChangeEmailRequest struct {
+	Email           string `json:"email"`
+	CurrentPassword string `json:"current_password"`
+}
+
+// Struktur untuk request penolakan akun dokter oleh admin
+type RejectDoctorRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Struktur untuk membuat klinik baru
+type OrganizationRequest struct {
+	Name        string `json:"name"`
+	Address     string `json:"address"`
+	PhoneNumber string `json:"phone_number"`
+}
+
+// Struktur untuk menambahkan anggota klinik berdasarkan username
+type AddMemberRequest struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// Struktur untuk membuat dan mengubah data pasien
+type PatientRequest struct {
+	NIK              string `json:"nik"`
+	BPJSNumber       string `json:"bpjs_number"`
+	Name             string `json:"name"`
+	Gender           string `json:"gender"`
+	BirthDate        string `json:"birth_date"`
+	Email            string `json:"email"`
+	PhoneNumber      string `json:"phone_number"`
+	Language         string `json:"language"`
+	PreferredChannel string `json:"preferred_channel"`
+}
+
+// Struktur untuk mencatat tanda vital. Satuan boleh dikosongkan untuk memakai satuan baku (mmHg, °C, cm, kg).
+type VitalSignRequest struct {
+	MeasuredAt        *time.Time `json:"measured_at"`
+	SystolicBP        *float64   `json:"systolic_bp"`
+	DiastolicBP       *float64   `json:"diastolic_bp"`
+	BloodPressureUnit string     `json:"blood_pressure_unit"`
+	PulseRate         *int       `json:"pulse_rate"`
+	RespiratoryRate   *int       `json:"respiratory_rate"`
+	Temperature       *float64   `json:"temperature"`
+	TemperatureUnit   string     `json:"temperature_unit"`
+	SpO2              *int       `json:"spo2"`
+	Height            *float64   `json:"height"`
+	HeightUnit        string     `json:"height_unit"`
+	Weight            *float64   `json:"weight"`
+	WeightUnit        string     `json:"weight_unit"`
+}
+
+// Struktur untuk mencatat alergi pasien
+type AllergyRequest struct {
+	Substance string `json:"substance"`
+	KFACode   string `json:"kfa_code"`
+	Reaction  string `json:"reaction"`
+	Severity  string `json:"severity"`
+}
+
+// Struktur untuk menambah dan mengubah problem list pasien
+type ProblemRequest struct {
+	ICD10Code    string `json:"icd10_code"`
+	Description  string `json:"description"`
+	Status       string `json:"status"`
+	OnsetDate    string `json:"onset_date"`
+	ResolvedDate string `json:"resolved_date"`
+}
+
+// PrescriptionCheckRequest dipakai untuk memeriksa resep sebelum rekam medis disimpan.
+// PatientID opsional, jika diisi resep juga dicocokkan dengan alergi pasien.
+type PrescriptionCheckRequest struct {
+	PatientID         uint                      `json:"patient_id"`
+	PrescriptionItems []models.PrescriptionItem `json:"prescription_items"`
+}
+
+// AvailabilityRequest adalah jadwal praktik mingguan. Weekday 0 = Minggu sampai 6 = Sabtu, jam dalam format HH:MM.
+type AvailabilityRequest struct {
+	Weekday     int    `json:"weekday"`
+	StartTime   string `json:"start_time"`
+	EndTime     string `json:"end_time"`
+	SlotMinutes int    `json:"slot_minutes"`
+}
+
+type LeaveRequest struct {
+	Date   string `json:"date"`
+	Reason string `json:"reason"`
+}
+
+type AppointmentRequest struct {
+	DoctorID  uint      `json:"doctor_id"`
+	PatientID uint      `json:"patient_id"`
+	StartsAt  time.Time `json:"starts_at"`
+	Reason    string    `json:"reason"`
+}
+
+type RescheduleAppointmentRequest struct {
+	StartsAt time.Time `json:"starts_at"`
+}
+
+type CancelAppointmentRequest struct {
+	Reason string `json:"reason"`
+}
+
+type CompleteAppointmentRequest struct {
+	MedicalRecordID uint `json:"medical_record_id"`
+}
+
+type QueueCheckInRequest struct {
+	DoctorID      uint  `json:"doctor_id"`
+	PatientID     uint  `json:"patient_id"`
+	AppointmentID *uint `json:"appointment_id"`
+}
+
+// QueueDoctorRequest dipakai untuk aksi yang menyangkut antrian satu dokter. DoctorID kosong berarti dokter yang login.
+type QueueDoctorRequest struct {
+	DoctorID uint `json:"doctor_id"`
+}
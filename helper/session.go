@@ -0,0 +1,177 @@
+package helper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"gorm.io/gorm"
+	"medis/auth"
+	"medis/models"
+	"time"
+)
+
+var ErrInvalidRefreshToken = errors.New("Invalid or expired refresh token")
+
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueDoctorSession membuat sesi baru (family baru) untuk dokter yang berhasil login di klinik tertentu
+func IssueDoctorSession(db *gorm.DB, doctor *models.Doctor, organizationID uint, keys *auth.KeySet) (*TokenPair, error) {
+	pair, _, err := issueRefreshToken(db, doctor, GenerateUniqueToken(), organizationID, keys)
+	return pair, err
+}
+
+func issueRefreshToken(db *gorm.DB, doctor *models.Doctor, familyID string, organizationID uint, keys *auth.KeySet) (*TokenPair, uint, error) {
+	rawToken := GenerateUniqueToken()
+	refreshToken := models.RefreshToken{
+		DoctorID:       doctor.ID,
+		FamilyID:       familyID,
+		OrganizationID: organizationID,
+		TokenHash:      HashToken(rawToken),
+		ExpiresAt:      time.Now().Add(auth.RefreshTokenDuration),
+	}
+	if err := db.Create(&refreshToken).Error; err != nil {
+		return nil, 0, err
+	}
+
+	accessToken, err := auth.GenerateToken(auth.Claims{
+		Username:       doctor.Username,
+		Role:           doctor.Role,
+		SessionID:      refreshToken.ID,
+		OrganizationID: organizationID,
+	}, keys)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: rawToken,
+		ExpiresIn:    int64(auth.AccessTokenDuration.Seconds()),
+	}, refreshToken.ID, nil
+}
+
+// RotateRefreshToken menukar refresh token dengan pasangan token baru. Token lama langsung dicabut,
+// dan jika token yang sudah dirotasi dipakai lagi maka seluruh family sesi tersebut ikut dicabut.
+func RotateRefreshToken(db *gorm.DB, rawToken string, keys *auth.KeySet) (*TokenPair, error) {
+	var pair *TokenPair
+	reused := false
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var current models.RefreshToken
+		if err := tx.Where("token_hash = ?", HashToken(rawToken)).First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+
+		if current.RevokedAt != nil {
+			reused = current.ReplacedByID != nil
+			return ErrInvalidRefreshToken
+		}
+		if time.Now().After(current.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		var doctor models.Doctor
+		if err := tx.First(&doctor, current.DoctorID).Error; err != nil {
+			return ErrInvalidRefreshToken
+		}
+
+		// Update bersyarat agar dua request refresh bersamaan tidak sama-sama berhasil
+		now := time.Now()
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", current.ID).
+			Update("revoked_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return ErrInvalidRefreshToken
+		}
+
+		// Jika dokter sudah dikeluarkan dari klinik sesi ini, pindahkan ke klinik default miliknya
+		organizationID := current.OrganizationID
+		if _, err := GetMembership(tx, organizationID, doctor.ID); err != nil {
+			if organizationID, err = ResolveOrganizationID(tx, doctor.ID, 0); err != nil {
+				return err
+			}
+		}
+
+		newPair, replacementID, err := issueRefreshToken(tx, &doctor, current.FamilyID, organizationID, keys)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RefreshToken{}).Where("id = ?", current.ID).Update("replaced_by_id", replacementID).Error; err != nil {
+			return err
+		}
+
+		pair = newPair
+		return nil
+	})
+
+	if reused {
+		revokeFamilyByToken(db, rawToken)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+func revokeFamilyByToken(db *gorm.DB, rawToken string) {
+	var token models.RefreshToken
+	if err := db.Where("token_hash = ?", HashToken(rawToken)).First(&token).Error; err != nil {
+		return
+	}
+	db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", token.FamilyID).
+		Update("revoked_at", time.Now())
+}
+
+// RevokeSession mencabut satu sesi login (refresh token aktif beserta access token yang terikat padanya)
+func RevokeSession(db *gorm.DB, sessionID uint) error {
+	var token models.RefreshToken
+	if err := db.First(&token, sessionID).Error; err != nil {
+		return err
+	}
+	return db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", token.FamilyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllSessions mencabut semua sesi milik dokter, misalnya saat password diganti
+func RevokeAllSessions(db *gorm.DB, doctorID uint) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("doctor_id = ? AND revoked_at IS NULL", doctorID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeOtherSessions mencabut semua sesi milik dokter kecuali sesi yang sedang dipakai
+func RevokeOtherSessions(db *gorm.DB, doctorID uint, sessionID uint) error {
+	var current models.RefreshToken
+	if err := db.First(&current, sessionID).Error; err != nil {
+		return err
+	}
+	return db.Model(&models.RefreshToken{}).
+		Where("doctor_id = ? AND family_id <> ? AND revoked_at IS NULL", doctorID, current.FamilyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// IsSessionActive dipakai saat verifikasi access token, token dari sesi yang sudah dicabut atau dirotasi ditolak
+func IsSessionActive(db *gorm.DB, sessionID uint, doctorID uint) bool {
+	var token models.RefreshToken
+	if err := db.Where("id = ? AND doctor_id = ?", sessionID, doctorID).First(&token).Error; err != nil {
+		return false
+	}
+	return token.RevokedAt == nil && time.Now().Before(token.ExpiresAt)
+}
@@ -0,0 +1,92 @@
+package helper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"medis/appconfig"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Nama query parameter pada link bertanda tangan
+const (
+	signedLinkExpiresParam   = "expires"
+	signedLinkSignatureParam = "signature"
+)
+
+var (
+	ErrInvalidSignedLink = errors.New("invalid link signature")
+	ErrExpiredSignedLink = errors.New("link has expired")
+)
+
+/*
+SignedLink membuat link publik yang bisa dibuka tanpa login sampai expiresAt. Tanda tangan HMAC-SHA256 mencakup path,
+semua query dan waktu kedaluwarsa, sehingga link tidak bisa diubah untuk membuka data lain atau diperpanjang.
+*/
+func SignedLink(path string, query url.Values, expiresAt time.Time) string {
+	config := appconfig.Get()
+	signed := url.Values{}
+	for key, values := range query {
+		signed[key] = append([]string(nil), values...)
+	}
+	signed.Set(signedLinkExpiresParam, strconv.FormatInt(expiresAt.Unix(), 10))
+	signed.Set(signedLinkSignatureParam, linkSignature(config.LinkSigningKey, path, signed))
+	return config.URL(path, signed)
+}
+
+// VerifySignedLink memeriksa tanda tangan dan masa berlaku link yang dibuat SignedLink untuk path yang sama
+func VerifySignedLink(path string, query url.Values, now time.Time) error {
+	signature, err := base64.RawURLEncoding.DecodeString(query.Get(signedLinkSignatureParam))
+	if err != nil || len(signature) == 0 {
+		return ErrInvalidSignedLink
+	}
+	expected, _ := base64.RawURLEncoding.DecodeString(linkSignature(appconfig.Get().LinkSigningKey, path, query))
+	if !hmac.Equal(signature, expected) {
+		return ErrInvalidSignedLink
+	}
+
+	expires, err := strconv.ParseInt(query.Get(signedLinkExpiresParam), 10, 64)
+	if err != nil {
+		return ErrInvalidSignedLink
+	}
+	if !now.Before(time.Unix(expires, 0)) {
+		return ErrExpiredSignedLink
+	}
+	return nil
+}
+
+// linkSignature menandatangani path dan query tanpa parameter signature. url.Values.Encode mengurutkan key
+// sehingga urutan query di link tidak memengaruhi hasil.
+func linkSignature(key []byte, path string, query url.Values) string {
+	unsigned := url.Values{}
+	for name, values := range query {
+		if name != signedLinkSignatureParam {
+			unsigned[name] = values
+		}
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(path + "?" + unsigned.Encode()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func VerificationLink(token string) string {
+	return appconfig.Get().URL("/verify", url.Values{"token": {token}})
+}
+
+func PasswordResetLink(token string) string {
+	return appconfig.Get().URL("/reset-password", url.Values{"token": {token}})
+}
+
+func MedicalRecordPortalPath(medicalRecordID uint) string {
+	return fmt.Sprintf("/portal/records/%d", medicalRecordID)
+}
+
+// MedicalRecordPortalLink membuat link portal pasien untuk mengunduh PDF rekam medis tanpa login
+func MedicalRecordPortalLink(medicalRecordID uint, now time.Time) (string, time.Time) {
+	expiresAt := now.Add(appconfig.Get().PortalLinkTTL)
+	return SignedLink(MedicalRecordPortalPath(medicalRecordID), nil, expiresAt), expiresAt
+}
@@ -0,0 +1,106 @@
+package helper
+
+import (
+	"errors"
+	"medis/appconfig"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func useTestAppConfig(t *testing.T) {
+	t.Helper()
+	appconfig.Set(&appconfig.Config{
+		PublicBaseURL:  "https://health.example.com",
+		LinkSigningKey: []byte("0123456789abcdef0123456789abcdef"),
+		PortalLinkTTL:  appconfig.DefaultPortalLinkTTL,
+	})
+	t.Cleanup(func() { appconfig.Set(nil) })
+}
+
+// parseSignedLink memisahkan path dan query dari link absolut supaya bisa diperiksa seperti request yang masuk
+func parseSignedLink(t *testing.T, link string) (string, url.Values) {
+	t.Helper()
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Path, parsed.Query()
+}
+
+func TestVerifySignedLink(t *testing.T) {
+	useTestAppConfig(t)
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(time.Hour)
+	link := SignedLink("/portal/records/7", url.Values{"lang": {"id"}}, expiresAt)
+	if !strings.HasPrefix(link, "https://health.example.com/portal/records/7?") {
+		t.Fatalf("SignedLink() = %q, want a link under PUBLIC_BASE_URL", link)
+	}
+	path, query := parseSignedLink(t, link)
+
+	tamperedQuery := func(key, value string) url.Values {
+		changed := url.Values{}
+		for name, values := range query {
+			changed[name] = append([]string(nil), values...)
+		}
+		changed.Set(key, value)
+		return changed
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		query url.Values
+		at    time.Time
+		want  error
+	}{
+		{"valid link", path, query, now, nil},
+		{"valid until just before expiry", path, query, expiresAt.Add(-time.Second), nil},
+		{"expired at expiry time", path, query, expiresAt, ErrExpiredSignedLink},
+		{"expired later", path, query, expiresAt.Add(24 * time.Hour), ErrExpiredSignedLink},
+		{"tampered path", "/portal/records/8", query, now, ErrInvalidSignedLink},
+		{"tampered query", path, tamperedQuery("lang", "en"), now, ErrInvalidSignedLink},
+		{"added query", path, tamperedQuery("download", "1"), now, ErrInvalidSignedLink},
+		{"extended expiry", path, tamperedQuery(signedLinkExpiresParam, "4102444800"), now, ErrInvalidSignedLink},
+		{"tampered signature", path, tamperedQuery(signedLinkSignatureParam, "AAAA"), now, ErrInvalidSignedLink},
+		{"missing signature", path, tamperedQuery(signedLinkSignatureParam, ""), now, ErrInvalidSignedLink},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := VerifySignedLink(test.path, test.query, test.at); !errors.Is(err, test.want) {
+				t.Errorf("VerifySignedLink() = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestVerifySignedLinkRejectsOtherKey(t *testing.T) {
+	useTestAppConfig(t)
+	now := time.Now()
+	path, query := parseSignedLink(t, SignedLink("/portal/records/7", nil, now.Add(time.Hour)))
+
+	appconfig.Set(&appconfig.Config{
+		PublicBaseURL:  "https://health.example.com",
+		LinkSigningKey: []byte("fedcba9876543210fedcba9876543210"),
+	})
+	if err := VerifySignedLink(path, query, now); !errors.Is(err, ErrInvalidSignedLink) {
+		t.Errorf("VerifySignedLink() with a different key = %v, want ErrInvalidSignedLink", err)
+	}
+}
+
+func TestMedicalRecordPortalLink(t *testing.T) {
+	useTestAppConfig(t)
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	link, expiresAt := MedicalRecordPortalLink(42, now)
+	if want := now.Add(appconfig.DefaultPortalLinkTTL); !expiresAt.Equal(want) {
+		t.Errorf("MedicalRecordPortalLink() expires at %v, want %v", expiresAt, want)
+	}
+	path, query := parseSignedLink(t, link)
+	if path != MedicalRecordPortalPath(42) {
+		t.Errorf("MedicalRecordPortalLink() path = %q, want %q", path, MedicalRecordPortalPath(42))
+	}
+	if err := VerifySignedLink(MedicalRecordPortalPath(42), query, now); err != nil {
+		t.Errorf("VerifySignedLink() on a fresh portal link = %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"medis/models"
+	"strings"
+)
+
+// Batas panjang setiap bagian SOAP. Assessment dan Plan mengikuti batas diagnosis dan care suggestion.
+const (
+	MaxSOAPSubjectiveLength = 5000
+	MaxSOAPObjectiveLength  = 5000
+	MaxSOAPAssessmentLength = 3000
+	MaxSOAPPlanLength       = 3000
+)
+
+// ValidateSOAPNote merapikan spasi dan memeriksa panjang setiap bagian SOAP.
+// Jika requireAll true (rekam medis baru dengan format SOAP), Assessment dan Plan wajib diisi.
+func ValidateSOAPNote(note models.SOAPNote, requireAll bool) (models.SOAPNote, error) {
+	note.Subjective = strings.TrimSpace(note.Subjective)
+	note.Objective = strings.TrimSpace(note.Objective)
+	note.Assessment = strings.TrimSpace(note.Assessment)
+	note.Plan = strings.TrimSpace(note.Plan)
+
+	sections := []struct {
+		name  string
+		value string
+		max   int
+	}{
+		{"Subjective", note.Subjective, MaxSOAPSubjectiveLength},
+		{"Objective", note.Objective, MaxSOAPObjectiveLength},
+		{"Assessment", note.Assessment, MaxSOAPAssessmentLength},
+		{"Plan", note.Plan, MaxSOAPPlanLength},
+	}
+	for _, section := range sections {
+		if len(section.value) > section.max {
+			return note, fmt.Errorf("SOAP %s must be at most %d characters", section.name, section.max)
+		}
+	}
+
+	if requireAll && (note.Assessment == "" || note.Plan == "") {
+		return note, errors.New("SOAP Assessment and Plan are required when using the SOAP layout")
+	}
+	return note, nil
+}
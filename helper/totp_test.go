@@ -0,0 +1,79 @@
+package helper
+
+import (
+	"testing"
+	"time"
+)
+
+// Kunci uji RFC 6238 untuk SHA1 ("12345678901234567890") dalam base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// Vektor uji RFC 6238 lampiran B untuk SHA1. RFC memakai 8 digit, kode 6 digit adalah 6 digit terakhirnya.
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	key, err := totpEncoding.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, vector := range rfc6238Vectors {
+		if got := totpCode(key, vector.unix/totpPeriod); got != vector.code {
+			t.Errorf("totpCode at %d = %s, want %s", vector.unix, got, vector.code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	for _, vector := range rfc6238Vectors {
+		step, ok := ValidateTOTP(rfc6238Secret, vector.code, time.Unix(vector.unix, 0))
+		if !ok || step != vector.unix/totpPeriod {
+			t.Errorf("ValidateTOTP at %d = (%d, %v), want (%d, true)", vector.unix, step, ok, vector.unix/totpPeriod)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		at     int64
+		want   bool
+	}{
+		{"previous period is accepted", rfc6238Secret, "287082", 59 + totpPeriod, true},
+		{"next period is accepted", rfc6238Secret, "081804", 1111111109 - totpPeriod, true},
+		{"two periods late is rejected", rfc6238Secret, "287082", 59 + 2*totpPeriod, false},
+		{"surrounding spaces are ignored", rfc6238Secret, " 287082 ", 59, true},
+		{"lowercase secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", "287082", 59, true},
+		{"wrong code", rfc6238Secret, "287083", 59, false},
+		{"eight digit code", rfc6238Secret, "94287082", 59, false},
+		{"invalid secret", "not base32!", "287082", 59, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(test.secret, test.code, time.Unix(test.at, 0)); ok != test.want {
+				t.Errorf("ValidateTOTP(%q, %q, %d) = %v, want %v", test.secret, test.code, test.at, ok, test.want)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecretRoundTrip(t *testing.T) {
+	secret := GenerateTOTPSecret()
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("GenerateTOTPSecret() = %q, want 20 random bytes in base32", secret)
+	}
+	now := time.Now()
+	if _, ok := ValidateTOTP(secret, totpCode(key, now.Unix()/totpPeriod), now); !ok {
+		t.Error("code generated from a new secret was rejected")
+	}
+}
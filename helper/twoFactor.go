@@ -0,0 +1,90 @@
+package helper
+
+import (
+	"crypto/rand"
+	"errors"
+	"gorm.io/gorm"
+	"medis/models"
+	"strings"
+	"time"
+)
+
+const recoveryCodeCount = 10
+
+var ErrInvalidSecondFactor = errors.New("Invalid authentication code")
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
+
+// GenerateRecoveryCodes mengganti semua recovery code lama dengan set baru dan mengembalikan kode aslinya sekali saja
+func GenerateRecoveryCodes(db *gorm.DB, doctorID uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		bytes := make([]byte, 5)
+		if _, err := rand.Read(bytes); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(totpEncoding.EncodeToString(bytes))
+		code := raw[:4] + "-" + raw[4:]
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{
+			DoctorID: doctorID,
+			CodeHash: HashToken(normalizeRecoveryCode(code)),
+		})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("doctor_id = ?", doctorID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&records).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// VerifySecondFactor menerima kode TOTP atau recovery code. Kode TOTP yang sudah dipakai
+// dan recovery code yang sudah terpakai ditolak supaya tidak bisa diputar ulang.
+func VerifySecondFactor(db *gorm.DB, doctor *models.Doctor, code, recoveryCode string) error {
+	if doctor.TOTPSecret == "" {
+		return ErrInvalidSecondFactor
+	}
+
+	if code != "" {
+		step, ok := ValidateTOTP(doctor.TOTPSecret, code, time.Now())
+		if !ok {
+			return ErrInvalidSecondFactor
+		}
+		result := db.Model(&models.Doctor{}).
+			Where("id = ? AND totp_last_used_step < ?", doctor.ID, step).
+			Update("totp_last_used_step", step)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return ErrInvalidSecondFactor
+		}
+		doctor.TOTPLastUsedStep = step
+		return nil
+	}
+
+	if recoveryCode != "" {
+		result := db.Model(&models.RecoveryCode{}).
+			Where("doctor_id = ? AND code_hash = ? AND used_at IS NULL", doctor.ID, HashToken(normalizeRecoveryCode(recoveryCode))).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return ErrInvalidSecondFactor
+		}
+		return nil
+	}
+
+	return ErrInvalidSecondFactor
+}
@@ -55,3 +55,16 @@ func ValidatePhoneNumber(phone string) bool {
 	re := regexp.MustCompile(`^\d{10,13}$`)
 	return re.MatchString(phone)
 }
+
+func ValidateLicenseNumber(number string) bool {
+	re := regexp.MustCompile(`^[A-Za-z0-9./-]{5,50}$`)
+	return re.MatchString(number)
+}
+
+// IsLicenseExpired memeriksa tanggal berakhir izin praktik (yyyy-mm-dd). Izin masih berlaku sampai akhir hari tersebut.
+func IsLicenseExpired(expiryDate string, now time.Time) bool {
+	if expiryDate == "" {
+		return false
+	}
+	return expiryDate < now.Format("2006-01-02")
+}
@@ -7,31 +7,69 @@ import (
 	"medis/auth"
 	"medis/models"
 	"strings"
+	"time"
 )
 
-func VerifyDoctorToken(db *gorm.DB, c echo.Context, secretKey []byte) (*models.Doctor, error) {
+// Masa berlaku link verifikasi email dan link reset password, serta jeda minimum sebelum link baru boleh dikirim ulang
+const (
+	VerificationTokenDuration  = 24 * time.Hour
+	VerificationResendCooldown = 2 * time.Minute
+	PasswordResetTokenDuration = 1 * time.Hour
+	PasswordResetCooldown      = 2 * time.Minute
+)
+
+// NewVerificationToken membuat token verifikasi baru untuk dokter dan mengembalikan token tersebut
+func NewVerificationToken(doctor *models.Doctor) string {
+	now := time.Now()
+	expiresAt := now.Add(VerificationTokenDuration)
+
+	doctor.VerificationToken = GenerateUniqueToken()
+	doctor.VerificationExpiresAt = &expiresAt
+	doctor.VerificationSentAt = &now
+	doctor.VerificationUsedAt = nil
+	return doctor.VerificationToken
+}
+
+func VerifyDoctorToken(db *gorm.DB, c echo.Context, keys *auth.KeySet) (*models.Doctor, *auth.Claims, error) {
 	tokenString := c.Request().Header.Get("Authorization")
 	if tokenString == "" {
-		return nil, errors.New("Authorization token is missing")
+		return nil, nil, errors.New("Authorization token is missing")
 	}
 
 	authParts := strings.SplitN(tokenString, " ", 2)
 	if len(authParts) != 2 || authParts[0] != "Bearer" {
-		return nil, errors.New("Invalid token format")
+		return nil, nil, errors.New("Invalid token format")
 	}
 
 	tokenString = authParts[1]
 
-	username, err := auth.VerifyToken(tokenString, secretKey)
-	if err != nil {
-		return nil, errors.New("Invalid token")
+	claims, err := auth.VerifyToken(tokenString, keys)
+	if err != nil || claims.Purpose != "" {
+		return nil, nil, errors.New("Invalid token")
 	}
 
 	var doctor models.Doctor
-	result := db.Where("username = ?", username).First(&doctor)
+	result := db.Where("username = ?", claims.Username).First(&doctor)
 	if result.Error != nil {
-		return nil, errors.New("Doctor not found")
+		return nil, nil, errors.New("Doctor not found")
+	}
+
+	// Token dari sesi yang sudah logout atau sudah dirotasi tidak boleh dipakai lagi
+	if !IsSessionActive(db, claims.SessionID, doctor.ID) {
+		return nil, nil, errors.New("Session has been revoked")
+	}
+
+	// Dokter yang sudah dikeluarkan dari klinik tidak boleh lagi mengakses data klinik tersebut
+	if claims.OrganizationID != 0 {
+		if _, err := GetMembership(db, claims.OrganizationID, doctor.ID); err != nil {
+			return nil, nil, errors.New("Organization membership has been revoked, please refresh your token")
+		}
+	}
+
+	// Role yang diubah admin langsung berlaku, token lama harus di-refresh dulu
+	if claims.Role != doctor.Role {
+		return nil, nil, errors.New("Role has changed, please refresh your token")
 	}
 
-	return &doctor, nil
+	return &doctor, claims, nil
 }
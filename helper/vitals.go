@@ -0,0 +1,211 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"medis/models"
+	"strings"
+	"time"
+)
+
+// Satuan baku yang dipakai untuk menyimpan dan menampilkan tanda vital
+var VitalSignUnits = map[string]string{
+	"systolic_bp":      "mmHg",
+	"diastolic_bp":     "mmHg",
+	"pulse_rate":       "beats/min",
+	"respiratory_rate": "breaths/min",
+	"temperature":      "°C",
+	"spo2":             "%",
+	"height":           "cm",
+	"weight":           "kg",
+	"bmi":              "kg/m²",
+}
+
+// Rentang nilai yang masih masuk akal secara klinis, dalam satuan baku. Nilai di luar rentang ini hampir pasti salah ketik.
+const (
+	minSystolicBP      = 50
+	maxSystolicBP      = 300
+	minDiastolicBP     = 20
+	maxDiastolicBP     = 200
+	minPulseRate       = 20
+	maxPulseRate       = 250
+	minRespiratoryRate = 4
+	maxRespiratoryRate = 80
+	minTemperature     = 30.0
+	maxTemperature     = 45.0
+	minSpO2            = 50
+	maxSpO2            = 100
+	minHeight          = 30.0
+	maxHeight          = 250.0
+	minWeight          = 0.5
+	maxWeight          = 400.0
+)
+
+func roundTo(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
+
+// CalculateBMI menghitung indeks massa tubuh dari berat (kg) dan tinggi (cm), dibulatkan satu desimal
+func CalculateBMI(weightKg, heightCm float64) float64 {
+	heightM := heightCm / 100
+	return roundTo(weightKg/(heightM*heightM), 1)
+}
+
+func convertBloodPressure(value float64, unit string) (float64, error) {
+	switch strings.ToLower(unit) {
+	case "", "mmhg":
+		return value, nil
+	case "kpa":
+		return value * 7.50062, nil
+	}
+	return 0, fmt.Errorf("Blood pressure unit %q is not supported, use mmHg or kPa", unit)
+}
+
+func convertTemperature(value float64, unit string) (float64, error) {
+	switch strings.ToUpper(strings.TrimPrefix(unit, "°")) {
+	case "", "C":
+		return value, nil
+	case "F":
+		return (value - 32) * 5 / 9, nil
+	}
+	return 0, fmt.Errorf("Temperature unit %q is not supported, use C or F", unit)
+}
+
+func convertHeight(value float64, unit string) (float64, error) {
+	switch strings.ToLower(unit) {
+	case "", "cm":
+		return value, nil
+	case "m":
+		return value * 100, nil
+	case "in":
+		return value * 2.54, nil
+	}
+	return 0, fmt.Errorf("Height unit %q is not supported, use cm, m or in", unit)
+}
+
+func convertWeight(value float64, unit string) (float64, error) {
+	switch strings.ToLower(unit) {
+	case "", "kg":
+		return value, nil
+	case "g":
+		return value / 1000, nil
+	case "lb":
+		return value * 0.45359237, nil
+	}
+	return 0, fmt.Errorf("Weight unit %q is not supported, use kg, g or lb", unit)
+}
+
+func checkRange(name string, value, min, max float64, unit string) error {
+	if value < min || value > max {
+		return fmt.Errorf("%s must be between %s and %s %s", name, FormatNumber(min), FormatNumber(max), unit)
+	}
+	return nil
+}
+
+/*
+BuildVitalSign mengubah request menjadi models.VitalSign dalam satuan baku, memeriksa rentang nilai yang masuk akal,
+dan menghitung BMI jika tinggi dan berat diisi. Minimal satu tanda vital harus diisi.
+*/
+func BuildVitalSign(request VitalSignRequest, now time.Time) (models.VitalSign, error) {
+	var vitalSign models.VitalSign
+
+	vitalSign.MeasuredAt = now
+	if request.MeasuredAt != nil {
+		if request.MeasuredAt.After(now.Add(5 * time.Minute)) {
+			return vitalSign, errors.New("Measurement time cannot be in the future")
+		}
+		vitalSign.MeasuredAt = *request.MeasuredAt
+	}
+
+	if (request.SystolicBP == nil) != (request.DiastolicBP == nil) {
+		return vitalSign, errors.New("Systolic and diastolic blood pressure must be given together")
+	}
+	if request.SystolicBP != nil {
+		systolic, err := convertBloodPressure(*request.SystolicBP, request.BloodPressureUnit)
+		if err != nil {
+			return vitalSign, err
+		}
+		diastolic, _ := convertBloodPressure(*request.DiastolicBP, request.BloodPressureUnit)
+		if err := checkRange("Systolic blood pressure", systolic, minSystolicBP, maxSystolicBP, "mmHg"); err != nil {
+			return vitalSign, err
+		}
+		if err := checkRange("Diastolic blood pressure", diastolic, minDiastolicBP, maxDiastolicBP, "mmHg"); err != nil {
+			return vitalSign, err
+		}
+		if diastolic >= systolic {
+			return vitalSign, errors.New("Systolic blood pressure must be higher than diastolic blood pressure")
+		}
+		systolicValue, diastolicValue := int(math.Round(systolic)), int(math.Round(diastolic))
+		vitalSign.SystolicBP, vitalSign.DiastolicBP = &systolicValue, &diastolicValue
+	}
+
+	if request.PulseRate != nil {
+		if err := checkRange("Pulse rate", float64(*request.PulseRate), minPulseRate, maxPulseRate, "beats/min"); err != nil {
+			return vitalSign, err
+		}
+		vitalSign.PulseRate = request.PulseRate
+	}
+
+	if request.RespiratoryRate != nil {
+		if err := checkRange("Respiratory rate", float64(*request.RespiratoryRate), minRespiratoryRate, maxRespiratoryRate, "breaths/min"); err != nil {
+			return vitalSign, err
+		}
+		vitalSign.RespiratoryRate = request.RespiratoryRate
+	}
+
+	if request.Temperature != nil {
+		temperature, err := convertTemperature(*request.Temperature, request.TemperatureUnit)
+		if err != nil {
+			return vitalSign, err
+		}
+		if err := checkRange("Temperature", temperature, minTemperature, maxTemperature, "°C"); err != nil {
+			return vitalSign, err
+		}
+		temperature = roundTo(temperature, 1)
+		vitalSign.Temperature = &temperature
+	}
+
+	if request.SpO2 != nil {
+		if err := checkRange("SpO2", float64(*request.SpO2), minSpO2, maxSpO2, "%"); err != nil {
+			return vitalSign, err
+		}
+		vitalSign.SpO2 = request.SpO2
+	}
+
+	if request.Height != nil {
+		height, err := convertHeight(*request.Height, request.HeightUnit)
+		if err != nil {
+			return vitalSign, err
+		}
+		if err := checkRange("Height", height, minHeight, maxHeight, "cm"); err != nil {
+			return vitalSign, err
+		}
+		height = roundTo(height, 1)
+		vitalSign.Height = &height
+	}
+
+	if request.Weight != nil {
+		weight, err := convertWeight(*request.Weight, request.WeightUnit)
+		if err != nil {
+			return vitalSign, err
+		}
+		if err := checkRange("Weight", weight, minWeight, maxWeight, "kg"); err != nil {
+			return vitalSign, err
+		}
+		weight = roundTo(weight, 2)
+		vitalSign.Weight = &weight
+	}
+
+	if vitalSign.Height != nil && vitalSign.Weight != nil {
+		bmi := CalculateBMI(*vitalSign.Weight, *vitalSign.Height)
+		vitalSign.BMI = &bmi
+	}
+
+	if vitalSign.SystolicBP == nil && vitalSign.PulseRate == nil && vitalSign.RespiratoryRate == nil &&
+		vitalSign.Temperature == nil && vitalSign.SpO2 == nil && vitalSign.Height == nil && vitalSign.Weight == nil {
+		return vitalSign, errors.New("At least one vital sign must be given")
+	}
+	return vitalSign, nil
+}
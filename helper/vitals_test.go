@@ -0,0 +1,176 @@
+package helper
+
+import (
+	"math"
+	"medis/models"
+	"testing"
+	"time"
+)
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
+
+func intPtr(value int) *int {
+	return &value
+}
+
+func TestBuildVitalSignConversions(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	vitalSign, err := BuildVitalSign(VitalSignRequest{
+		SystolicBP:        floatPtr(16),
+		DiastolicBP:       floatPtr(10.7),
+		BloodPressureUnit: "kPa",
+		Temperature:       floatPtr(98.6),
+		TemperatureUnit:   "°F",
+		Height:            floatPtr(70),
+		HeightUnit:        "in",
+		Weight:            floatPtr(154),
+		WeightUnit:        "lb",
+	}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *vitalSign.SystolicBP != 120 || *vitalSign.DiastolicBP != 80 {
+		t.Errorf("blood pressure = %d/%d mmHg, want 120/80", *vitalSign.SystolicBP, *vitalSign.DiastolicBP)
+	}
+	if *vitalSign.Temperature != 37 {
+		t.Errorf("temperature = %v °C, want 37", *vitalSign.Temperature)
+	}
+	if *vitalSign.Height != 177.8 {
+		t.Errorf("height = %v cm, want 177.8", *vitalSign.Height)
+	}
+	if *vitalSign.Weight != 69.85 {
+		t.Errorf("weight = %v kg, want 69.85", *vitalSign.Weight)
+	}
+	if *vitalSign.BMI != 22.1 {
+		t.Errorf("BMI = %v, want 22.1", *vitalSign.BMI)
+	}
+	if !vitalSign.MeasuredAt.Equal(now) {
+		t.Errorf("measured at = %v, want %v", vitalSign.MeasuredAt, now)
+	}
+}
+
+func TestBuildVitalSignUnitConversions(t *testing.T) {
+	systolic := func(vitalSign models.VitalSign) float64 { return float64(*vitalSign.SystolicBP) }
+	temperature := func(vitalSign models.VitalSign) float64 { return *vitalSign.Temperature }
+	height := func(vitalSign models.VitalSign) float64 { return *vitalSign.Height }
+	weight := func(vitalSign models.VitalSign) float64 { return *vitalSign.Weight }
+
+	tests := []struct {
+		name    string
+		request VitalSignRequest
+		get     func(vitalSign models.VitalSign) float64
+		want    float64
+	}{
+		{"mmHg is the default", VitalSignRequest{SystolicBP: floatPtr(130), DiastolicBP: floatPtr(85)}, systolic, 130},
+		{"kPa", VitalSignRequest{SystolicBP: floatPtr(20), DiastolicBP: floatPtr(12), BloodPressureUnit: "KPA"}, systolic, 150},
+		{"Celsius is the default", VitalSignRequest{Temperature: floatPtr(36.56)}, temperature, 36.6},
+		{"Fahrenheit without degree sign", VitalSignRequest{Temperature: floatPtr(104), TemperatureUnit: "f"}, temperature, 40},
+		{"metres", VitalSignRequest{Height: floatPtr(1.65), HeightUnit: "m"}, height, 165},
+		{"inches", VitalSignRequest{Height: floatPtr(70), HeightUnit: "in"}, height, 177.8},
+		{"grams", VitalSignRequest{Weight: floatPtr(3250), WeightUnit: "g"}, weight, 3.25},
+		{"pounds", VitalSignRequest{Weight: floatPtr(10), WeightUnit: "lb"}, weight, 4.54},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vitalSign, err := BuildVitalSign(test.request, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := test.get(vitalSign); math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBuildVitalSignRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		request VitalSignRequest
+		wantErr bool
+	}{
+		{"lowest systolic", VitalSignRequest{SystolicBP: floatPtr(50), DiastolicBP: floatPtr(20)}, false},
+		{"highest systolic", VitalSignRequest{SystolicBP: floatPtr(300), DiastolicBP: floatPtr(200)}, false},
+		{"systolic below range", VitalSignRequest{SystolicBP: floatPtr(49), DiastolicBP: floatPtr(20)}, true},
+		{"systolic above range", VitalSignRequest{SystolicBP: floatPtr(301), DiastolicBP: floatPtr(80)}, true},
+		{"diastolic below range", VitalSignRequest{SystolicBP: floatPtr(120), DiastolicBP: floatPtr(19)}, true},
+		{"diastolic not below systolic", VitalSignRequest{SystolicBP: floatPtr(120), DiastolicBP: floatPtr(120)}, true},
+		{"systolic without diastolic", VitalSignRequest{SystolicBP: floatPtr(120)}, true},
+		{"unknown blood pressure unit", VitalSignRequest{SystolicBP: floatPtr(120), DiastolicBP: floatPtr(80), BloodPressureUnit: "bar"}, true},
+		{"lowest pulse rate", VitalSignRequest{PulseRate: intPtr(20)}, false},
+		{"highest pulse rate", VitalSignRequest{PulseRate: intPtr(250)}, false},
+		{"pulse rate above range", VitalSignRequest{PulseRate: intPtr(251)}, true},
+		{"lowest respiratory rate", VitalSignRequest{RespiratoryRate: intPtr(4)}, false},
+		{"respiratory rate below range", VitalSignRequest{RespiratoryRate: intPtr(3)}, true},
+		{"respiratory rate above range", VitalSignRequest{RespiratoryRate: intPtr(81)}, true},
+		{"lowest temperature", VitalSignRequest{Temperature: floatPtr(30)}, false},
+		{"highest temperature", VitalSignRequest{Temperature: floatPtr(45)}, false},
+		{"temperature above range", VitalSignRequest{Temperature: floatPtr(45.1)}, true},
+		{"Fahrenheit above range", VitalSignRequest{Temperature: floatPtr(114), TemperatureUnit: "F"}, true},
+		{"unknown temperature unit", VitalSignRequest{Temperature: floatPtr(310), TemperatureUnit: "K"}, true},
+		{"lowest SpO2", VitalSignRequest{SpO2: intPtr(50)}, false},
+		{"highest SpO2", VitalSignRequest{SpO2: intPtr(100)}, false},
+		{"SpO2 above range", VitalSignRequest{SpO2: intPtr(101)}, true},
+		{"lowest height", VitalSignRequest{Height: floatPtr(30)}, false},
+		{"height above range", VitalSignRequest{Height: floatPtr(250.1)}, true},
+		{"height in metres above range", VitalSignRequest{Height: floatPtr(2.6), HeightUnit: "m"}, true},
+		{"lowest weight", VitalSignRequest{Weight: floatPtr(0.5)}, false},
+		{"highest weight", VitalSignRequest{Weight: floatPtr(400)}, false},
+		{"weight below range", VitalSignRequest{Weight: floatPtr(0.4)}, true},
+		{"weight in pounds above range", VitalSignRequest{Weight: floatPtr(900), WeightUnit: "lb"}, true},
+		{"unknown weight unit", VitalSignRequest{Weight: floatPtr(10), WeightUnit: "stone"}, true},
+		{"no vital signs", VitalSignRequest{}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := BuildVitalSign(test.request, time.Now())
+			if (err != nil) != test.wantErr {
+				t.Errorf("BuildVitalSign() error = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildVitalSignMeasuredAt(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		measuredAt time.Time
+		wantErr    bool
+	}{
+		{"in the past", now.Add(-2 * time.Hour), false},
+		{"clock skew is tolerated", now.Add(5 * time.Minute), false},
+		{"in the future", now.Add(5*time.Minute + time.Second), true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			measuredAt := test.measuredAt
+			vitalSign, err := BuildVitalSign(VitalSignRequest{MeasuredAt: &measuredAt, PulseRate: intPtr(80)}, now)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("BuildVitalSign() error = %v, want error %v", err, test.wantErr)
+			}
+			if err == nil && !vitalSign.MeasuredAt.Equal(measuredAt) {
+				t.Errorf("measured at = %v, want %v", vitalSign.MeasuredAt, measuredAt)
+			}
+		})
+	}
+}
+
+func TestCalculateBMI(t *testing.T) {
+	tests := []struct {
+		weight, height, want float64
+	}{
+		{70, 175, 22.9},
+		{50, 160, 19.5},
+		{100, 200, 25},
+	}
+	for _, test := range tests {
+		if got := CalculateBMI(test.weight, test.height); got != test.want {
+			t.Errorf("CalculateBMI(%v, %v) = %v, want %v", test.weight, test.height, got, test.want)
+		}
+	}
+}
@@ -1,17 +1,39 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"medis/config"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+// Batas waktu menunggu request yang sedang berjalan saat server dihentikan
+const shutdownTimeout = 10 * time.Second
+
 /*
-Function main untuk memulai server pada port 8080
+Function main untuk memulai server pada port 8080. SIGINT atau SIGTERM menghentikan worker outbox dan menunggu
+request yang sedang berjalan selesai sebelum server ditutup.
 */
 func main() {
-	router := config.SetupRouter()
-	err := router.Start(":8080")
-	if err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	router := config.SetupRouter(ctx)
+	go func() {
+		if err := router.Start(":8080"); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := router.Shutdown(shutdownCtx); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"medis/helper"
+	"net/http"
+)
+
+func ValidateAvailability(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var request helper.AvailabilityRequest
+		if err := c.Bind(&request); err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body",
+			})
+		}
+
+		if request.Weekday < 0 || request.Weekday > 6 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Weekday must be between 0 (Sunday) and 6 (Saturday)",
+			})
+		}
+
+		start, okStart := helper.ParseClockTime(request.StartTime)
+		end, okEnd := helper.ParseClockTime(request.EndTime)
+		if !okStart || !okEnd {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Start and end time must be in the format HH:MM",
+			})
+		}
+		if end <= start {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "End time must be after start time",
+			})
+		}
+
+		if request.SlotMinutes == 0 {
+			request.SlotMinutes = helper.DefaultSlotMinutes
+		}
+		if request.SlotMinutes < helper.MinSlotMinutes || request.SlotMinutes > helper.MaxSlotMinutes {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: fmt.Sprintf("Slot length must be between %d and %d minutes", helper.MinSlotMinutes, helper.MaxSlotMinutes),
+			})
+		}
+		if request.SlotMinutes > end-start {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Slot length cannot be longer than the availability window",
+			})
+		}
+
+		c.Set("availability", request)
+		return next(c)
+	}
+}
+
+func ValidateLeave(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var request helper.LeaveRequest
+		if err := c.Bind(&request); err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body",
+			})
+		}
+
+		if !helper.ValidateDateFormat(request.Date) {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Leave date must be in the format yyyy-mm-dd",
+			})
+		}
+
+		if len(request.Reason) > 255 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Reason must be at most 255 characters",
+			})
+		}
+
+		c.Set("leave", request)
+		return next(c)
+	}
+}
+
+func ValidateAppointment(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var request helper.AppointmentRequest
+		if err := c.Bind(&request); err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body. starts_at must be an RFC 3339 timestamp",
+			})
+		}
+
+		if request.DoctorID == 0 || request.PatientID == 0 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Doctor ID and patient ID are required",
+			})
+		}
+
+		if request.StartsAt.IsZero() {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Appointment start time is required",
+			})
+		}
+
+		if len(request.Reason) > 500 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Reason must be at most 500 characters",
+			})
+		}
+
+		c.Set("appointment", request)
+		return next(c)
+	}
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"medis/helper"
+	"net/http"
+	"strings"
+)
+
+func ValidateProfileUpdate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var request helper.UpdateProfileRequest
+		if err := c.Bind(&request); err != nil {
+			errorResponse := helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body",
+			}
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		}
+
+		if request.FirstName != "" {
+			if len(request.FirstName) > 100 || !helper.ValidateLettersAndSpaces(request.FirstName) {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "First Name must be between 1 and 100 characters and contain only letters",
+				})
+			}
+		}
+
+		if request.LastName != "" {
+			if len(request.LastName) > 100 || !helper.ValidateLettersAndSpaces(request.LastName) {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Last Name max 100 characters and contain only letters",
+				})
+			}
+		}
+
+		if request.ContactNumber != "" && !helper.ValidatePhoneNumber(request.ContactNumber) {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Contact number must be between 10 and 13 digits and contain only numbers",
+			})
+		}
+
+		if len(request.Gender) > 20 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Gender max 20 characters",
+			})
+		}
+
+		request.Language = strings.ToLower(strings.TrimSpace(request.Language))
+		if request.Language != "" && !helper.IsSupportedLocale(request.Language) {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Language must be either id or en",
+			})
+		}
+
+		c.Set("profileUpdate", request)
+		return next(c)
+	}
+}
+
+func ValidateChangePassword(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var request helper.ChangePasswordRequest
+		if err := c.Bind(&request); err != nil {
+			errorResponse := helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body",
+			}
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		}
+
+		if request.CurrentPassword == "" {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Current password is required",
+			})
+		}
+
+		if len(request.NewPassword) < 8 || len(request.NewPassword) > 100 || !helper.IsValidPassword(request.NewPassword) {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Password must be at least 8 characters max 100 characters and contain a combination of letters and numbers",
+			})
+		}
+
+		c.Set("changePassword", request)
+		return next(c)
+	}
+}
+
+func ValidateChangeEmail(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var request helper.ChangeEmailRequest
+		if err := c.Bind(&request); err != nil {
+			errorResponse := helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body",
+			}
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		}
+
+		request.Email = strings.TrimSpace(request.Email)
+		if !helper.ValidateEmailFormat(request.Email) {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid email format",
+			})
+		}
+
+		if request.CurrentPassword == "" {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Current password is required",
+			})
+		}
+
+		c.Set("changeEmail", request)
+		return next(c)
+	}
+}
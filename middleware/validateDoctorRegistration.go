@@ -4,16 +4,19 @@ import (
 	"errors"
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
+	"medis/auth"
 	"medis/helper"
 	"medis/models"
 	"net/http"
 	"regexp"
+	"strings"
+	"time"
 )
 
 func ValidateDoctorRegistration(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		var doctor models.Doctor
-		if err := c.Bind(&doctor); err != nil {
+		var request helper.DoctorRegistrationRequest
+		if err := c.Bind(&request); err != nil {
 			errorResponse := helper.ErrorResponse{
 				Code:    http.StatusBadRequest,
 				Message: err.Error(),
@@ -21,8 +24,26 @@ func ValidateDoctorRegistration(next echo.HandlerFunc) echo.HandlerFunc {
 			return c.JSON(http.StatusBadRequest, errorResponse)
 		}
 
-		uniqueToken := helper.GenerateUniqueToken()
-		doctor.VerificationToken = uniqueToken
+		doctor := models.Doctor{
+			FirstName:         request.FirstName,
+			LastName:          request.LastName,
+			ContactNumber:     request.ContactNumber,
+			Gender:            request.Gender,
+			Email:             request.Email,
+			Username:          request.Username,
+			Password:          request.Password,
+			STRNumber:         request.STRNumber,
+			SIPNumber:         request.SIPNumber,
+			Specialty:         request.Specialty,
+			LicenseExpiryDate: request.LicenseExpiryDate,
+			Language:          helper.NormalizeLocale(request.Language),
+		}
+
+		// Akun baru selalu berperan sebagai dokter dan menunggu persetujuan admin atas izin praktiknya
+		doctor.Role = auth.RoleDoctor
+		doctor.ApprovalStatus = models.ApprovalStatusPending
+		// Token verifikasi dikirim lewat email selamat datang setelah akun benar-benar tersimpan (lihat RegisterDoctor)
+		helper.NewVerificationToken(&doctor)
 
 		if len(doctor.FirstName) < 1 || len(doctor.FirstName) > 100 || !regexp.MustCompile(`^[a-zA-Z\s]+$`).MatchString(doctor.FirstName) {
 			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
@@ -71,10 +92,18 @@ func ValidateDoctorRegistration(next echo.HandlerFunc) echo.HandlerFunc {
 			})
 		}
 
-		if err := helper.SendWelcomeEmail(doctor.Email, doctor.FirstName+" "+doctor.LastName, uniqueToken); err != nil {
-			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
-				Code:    http.StatusInternalServerError,
-				Message: "Failed to send welcome email",
+		request.Language = strings.ToLower(strings.TrimSpace(request.Language))
+		if request.Language != "" && !helper.IsSupportedLocale(request.Language) {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Language must be either id or en",
+			})
+		}
+
+		if message := validateLicense(request.LicenseRequest); message != "" {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: message,
 			})
 		}
 
@@ -83,6 +112,79 @@ func ValidateDoctorRegistration(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// validateLicense mengembalikan pesan error pertama dari data izin praktik, atau string kosong jika valid
+func validateLicense(license helper.LicenseRequest) string {
+	if !helper.ValidateLicenseNumber(license.STRNumber) {
+		return "STR number must be between 5 and 50 characters and contain only letters, numbers, dots, slashes or dashes"
+	}
+
+	if !helper.ValidateLicenseNumber(license.SIPNumber) {
+		return "SIP number must be between 5 and 50 characters and contain only letters, numbers, dots, slashes or dashes"
+	}
+
+	if len(license.Specialty) < 1 || len(license.Specialty) > 100 || !helper.ValidateLettersAndSpaces(license.Specialty) {
+		return "Specialty must be between 1 and 100 characters and contain only letters"
+	}
+
+	if !helper.ValidateDateFormat(license.LicenseExpiryDate) {
+		return "License expiry date must be in the format yyyy-mm-dd"
+	}
+
+	if helper.IsLicenseExpired(license.LicenseExpiryDate, time.Now()) {
+		return "License has already expired"
+	}
+
+	return ""
+}
+
+func ValidateLicenseUpdate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var request helper.LicenseRequest
+		if err := c.Bind(&request); err != nil {
+			errorResponse := helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body",
+			}
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		}
+
+		if message := validateLicense(request); message != "" {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: message,
+			})
+		}
+
+		c.Set("license", request)
+		return next(c)
+	}
+}
+
+func ValidateResendVerification(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var request helper.ResendVerificationRequest
+		if err := c.Bind(&request); err != nil {
+			errorResponse := helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body",
+			}
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		}
+
+		request.Email = strings.TrimSpace(request.Email)
+		if !helper.ValidateEmailFormat(request.Email) {
+			errorResponse := helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid email format",
+			}
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		}
+
+		c.Set("resendVerification", request)
+		return next(c)
+	}
+}
+
 func CheckDoctorUniqueness(db *gorm.DB) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -102,7 +204,20 @@ func CheckDoctorUniqueness(db *gorm.DB) echo.MiddlewareFunc {
 				})
 			}
 
-			result = db.Where("email = ?", doctor.Email).First(&existingDoctor)
+			result = db.Where("str_number = ?", doctor.STRNumber).First(&existingDoctor)
+			if result.Error == nil {
+				return c.JSON(http.StatusConflict, helper.ErrorResponse{
+					Code:    http.StatusConflict,
+					Message: "STR number already registered",
+				})
+			} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+					Code:    http.StatusInternalServerError,
+					Message: "Failed to check STR number",
+				})
+			}
+
+			result = db.Where("email = ? OR pending_email = ?", doctor.Email, doctor.Email).First(&existingDoctor)
 			if result.Error == nil {
 				return c.JSON(http.StatusConflict, helper.ErrorResponse{
 					Code:    http.StatusConflict,
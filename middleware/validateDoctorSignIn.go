@@ -6,15 +6,20 @@ import (
 	"github.com/labstack/echo/v4"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"medis/auth"
 	"medis/helper"
 	"medis/models"
 	"net/http"
+	"strconv"
+	"time"
 )
 
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("medis-dummy-password"), bcrypt.DefaultCost)
+
 func ValidateDoctorSignIn(db *gorm.DB) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			var doctor models.Doctor
+			var doctor helper.SignInRequest
 			if err := c.Bind(&doctor); err != nil {
 				errorResponse := helper.ErrorResponse{
 					Code:    http.StatusBadRequest,
@@ -41,35 +46,50 @@ func ValidateDoctorSignIn(db *gorm.DB) echo.MiddlewareFunc {
 				return c.JSON(http.StatusBadRequest, errorResponse)
 			}
 
-			// Cek Username di Database
+			// Tolak lebih awal jika username atau IP sedang dikunci karena terlalu banyak gagal login
+			if lockedUntil := helper.LoginLockedUntil(db, doctor.Username, c.RealIP()); lockedUntil != nil {
+				return tooManySignInAttempts(c, *lockedUntil)
+			}
+
+			// Cek Username dan Password di Database. Pesan error sengaja disamakan agar username tidak bisa ditebak.
 			var existingDoctor models.Doctor
 			result := db.Where("username = ?", doctor.Username).First(&existingDoctor)
+			if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				errorResponse := helper.ErrorResponse{
+					Code:    http.StatusInternalServerError,
+					Message: "Failed to check username",
+				}
+				return c.JSON(http.StatusInternalServerError, errorResponse)
+			}
+
+			// Username yang tidak ada tetap melewati bcrypt supaya waktu respons tidak membocorkan apa pun
+			passwordHash := []byte(existingDoctor.Password)
 			if result.Error != nil {
-				if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-					errorResponse := helper.ErrorResponse{
-						Code:    http.StatusUnauthorized,
-						Message: "Invalid username",
-					}
-					return c.JSON(http.StatusUnauthorized, errorResponse)
-				} else {
-					errorResponse := helper.ErrorResponse{
-						Code:    http.StatusInternalServerError,
-						Message: "Failed to check username",
+				passwordHash = dummyPasswordHash
+			}
+			passwordErr := bcrypt.CompareHashAndPassword(passwordHash, []byte(doctor.Password))
+
+			if result.Error != nil || passwordErr != nil {
+				lockedUntil, newlyLocked := helper.RecordLoginFailure(db, doctor.Username, c.RealIP())
+				if newlyLocked && result.Error == nil {
+					if err := helper.EnqueueEmail(db, models.EmailKindAccountLockout, existingDoctor.Email, helper.EmailPayload{
+						Name:        existingDoctor.FirstName + " " + existingDoctor.LastName,
+						LockedUntil: lockedUntil,
+						Locale:      existingDoctor.Language,
+					}); err != nil {
+						fmt.Println("Failed to queue lockout notification email:", err)
 					}
-					return c.JSON(http.StatusInternalServerError, errorResponse)
 				}
-			}
 
-			// Cek Password
-			err := bcrypt.CompareHashAndPassword([]byte(existingDoctor.Password), []byte(doctor.Password))
-			if err != nil {
 				errorResponse := helper.ErrorResponse{
 					Code:    http.StatusUnauthorized,
-					Message: "Invalid password",
+					Message: "Invalid username or password",
 				}
 				return c.JSON(http.StatusUnauthorized, errorResponse)
 			}
 
+			helper.ResetLoginFailures(db, existingDoctor.Username)
+
 			// Cek Verifikasi Akun
 			if !existingDoctor.IsVerified {
 				errorResponse := helper.ErrorResponse{
@@ -79,23 +99,26 @@ func ValidateDoctorSignIn(db *gorm.DB) echo.MiddlewareFunc {
 				return c.JSON(http.StatusUnauthorized, errorResponse)
 			}
 
-			// Send Login Notification
-			go func(email, username string) {
-				if err := helper.SendLoginNotification(email, username); err != nil {
-					fmt.Println("Failed to send notification email:", err)
-				}
-			}(existingDoctor.Email, existingDoctor.FirstName+" "+existingDoctor.LastName)
-
 			c.Set("doctor", existingDoctor)
+			c.Set("organizationID", doctor.OrganizationID)
 			return next(c)
 		}
 	}
 }
 
-func VerifyDoctorTokenMiddleware(db *gorm.DB, secretKey []byte) echo.MiddlewareFunc {
+func tooManySignInAttempts(c echo.Context, lockedUntil time.Time) error {
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(time.Until(lockedUntil).Seconds())+1))
+	errorResponse := helper.ErrorResponse{
+		Code:    http.StatusTooManyRequests,
+		Message: "Too many failed sign-in attempts. Please try again later.",
+	}
+	return c.JSON(http.StatusTooManyRequests, errorResponse)
+}
+
+func VerifyDoctorTokenMiddleware(db *gorm.DB, keys *auth.KeySet) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			doctor, err := helper.VerifyDoctorToken(db, c, secretKey)
+			doctor, claims, err := helper.VerifyDoctorToken(db, c, keys)
 			if err != nil {
 				errorResponse := helper.ErrorResponse{
 					Code:    http.StatusUnauthorized,
@@ -103,8 +126,38 @@ func VerifyDoctorTokenMiddleware(db *gorm.DB, secretKey []byte) echo.MiddlewareF
 				}
 				return c.JSON(http.StatusUnauthorized, errorResponse)
 			}
-			// Store the doctor object in the context
+			// Store the doctor object and token claims in the context
 			c.Set("doctor", doctor)
+			c.Set("claims", claims)
+			return next(c)
+		}
+	}
+}
+
+// RequirePermission dipasang setelah VerifyDoctorTokenMiddleware dan menolak request
+// jika role pada token tidak memiliki semua permission yang diminta
+func RequirePermission(permissions ...auth.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, ok := c.Get("claims").(*auth.Claims)
+			if !ok {
+				errorResponse := helper.ErrorResponse{
+					Code:    http.StatusUnauthorized,
+					Message: "Authorization token is missing",
+				}
+				return c.JSON(http.StatusUnauthorized, errorResponse)
+			}
+
+			for _, permission := range permissions {
+				if !auth.HasPermission(claims.Role, permission) {
+					errorResponse := helper.ErrorResponse{
+						Code:    http.StatusForbidden,
+						Message: "You do not have permission to perform this action",
+					}
+					return c.JSON(http.StatusForbidden, errorResponse)
+				}
+			}
+
 			return next(c)
 		}
 	}
@@ -1,88 +1,139 @@
 package middleware
 
 import (
+	"errors"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 	"medis/helper"
 	"medis/models"
 	"net/http"
 )
 
-func ValidateMedicalRecord(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		var medicalRecord models.MedicalRecords
-		if err := c.Bind(&medicalRecord); err != nil {
-			errorResponse := helper.ErrorResponse{
-				Code:    http.StatusBadRequest,
-				Message: "Invalid request body",
+func ValidateMedicalRecord(db *gorm.DB) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var medicalRecord models.MedicalRecords
+			if err := c.Bind(&medicalRecord); err != nil {
+				errorResponse := helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Invalid request body",
+				}
+				return c.JSON(http.StatusBadRequest, errorResponse)
 			}
-			return c.JSON(http.StatusBadRequest, errorResponse)
-		}
 
-		if len(medicalRecord.PatientName) < 1 || len(medicalRecord.PatientName) > 100 || !helper.ValidateLettersAndSpaces(medicalRecord.PatientName) {
-			errorResponse := helper.ErrorResponse{
-				Code:    http.StatusBadRequest,
-				Message: "Patient name must be between 1 and 100 characters and contain only letters and spaces",
+			// Identitas pasien hanya wajib jika rekam medis tidak merujuk ke pasien yang sudah terdaftar atau ke janji temu
+			if medicalRecord.PatientID == 0 && medicalRecord.AppointmentID == nil {
+				if len(medicalRecord.PatientName) < 1 || len(medicalRecord.PatientName) > 100 || !helper.ValidateLettersAndSpaces(medicalRecord.PatientName) {
+					errorResponse := helper.ErrorResponse{
+						Code:    http.StatusBadRequest,
+						Message: "Patient name must be between 1 and 100 characters and contain only letters and spaces",
+					}
+					return c.JSON(http.StatusBadRequest, errorResponse)
+				}
+
+				if !helper.ValidateDateFormat(medicalRecord.BirthDate) {
+					errorResponse := helper.ErrorResponse{
+						Code:    http.StatusBadRequest,
+						Message: "Birth date must be in the format yyyy-mm-dd",
+					}
+					return c.JSON(http.StatusBadRequest, errorResponse)
+				}
+
+				if !helper.ValidateEmailFormat(medicalRecord.Email) {
+					errorResponse := helper.ErrorResponse{
+						Code:    http.StatusBadRequest,
+						Message: "Invalid email format",
+					}
+					return c.JSON(http.StatusBadRequest, errorResponse)
+				}
+
+				if !helper.ValidatePhoneNumber(medicalRecord.PhoneNumber) {
+					errorResponse := helper.ErrorResponse{
+						Code:    http.StatusBadRequest,
+						Message: "Phone number must contain only digits and be at most 13 characters long",
+					}
+					return c.JSON(http.StatusBadRequest, errorResponse)
+				}
 			}
-			return c.JSON(http.StatusBadRequest, errorResponse)
-		}
 
-		if !helper.ValidateDateFormat(medicalRecord.BirthDate) {
-			errorResponse := helper.ErrorResponse{
-				Code:    http.StatusBadRequest,
-				Message: "Birth date must be in the format yyyy-mm-dd",
+			// Format SOAP bersifat opsional. Jika dipakai, diagnosis dan care suggestion yang kosong diisi dari
+			// Assessment dan Plan supaya klien lama yang hanya membaca field tersebut tetap mendapat isinya.
+			if !medicalRecord.SOAP.IsEmpty() {
+				soap, err := helper.ValidateSOAPNote(medicalRecord.SOAP, true)
+				if err != nil {
+					return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+						Code:    http.StatusBadRequest,
+						Message: err.Error(),
+					})
+				}
+				medicalRecord.SOAP = soap
+				if medicalRecord.Diagnosis == "" {
+					medicalRecord.Diagnosis = soap.Assessment
+				}
+				if medicalRecord.CareSuggestion == "" {
+					medicalRecord.CareSuggestion = soap.Plan
+				}
 			}
-			return c.JSON(http.StatusBadRequest, errorResponse)
-		}
 
-		if !helper.ValidateEmailFormat(medicalRecord.Email) {
-			errorResponse := helper.ErrorResponse{
-				Code:    http.StatusBadRequest,
-				Message: "Invalid email format",
+			if len(medicalRecord.Diagnosis) < 1 || len(medicalRecord.Diagnosis) > 3000 {
+				errorResponse := helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Diagnosis must be between 1 and 3000 characters",
+				}
+				return c.JSON(http.StatusBadRequest, errorResponse)
 			}
-			return c.JSON(http.StatusBadRequest, errorResponse)
-		}
 
-		if !helper.ValidatePhoneNumber(medicalRecord.PhoneNumber) {
-			errorResponse := helper.ErrorResponse{
-				Code:    http.StatusBadRequest,
-				Message: "Phone number must contain only digits and be at most 13 characters long",
+			// Resep boleh berupa teks bebas atau daftar obat terstruktur. Jika hanya daftar obat yang dikirim,
+			// teks resep dibuat dari daftar tersebut supaya klien lama tetap bisa menampilkannya.
+			prescriptionItems, err := helper.ValidatePrescriptionItems(medicalRecord.PrescriptionItems)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: err.Error(),
+				})
 			}
-			return c.JSON(http.StatusBadRequest, errorResponse)
-		}
+			medicalRecord.PrescriptionItems = prescriptionItems
 
-		if len(medicalRecord.Diagnosis) < 1 || len(medicalRecord.Diagnosis) > 3000 {
-			errorResponse := helper.ErrorResponse{
-				Code:    http.StatusBadRequest,
-				Message: "Diagnosis must be between 1 and 3000 characters",
+			if (len(prescriptionItems) == 0 && len(medicalRecord.Prescription) < 1) || len(medicalRecord.Prescription) > 3000 {
+				errorResponse := helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Prescription must be between 1 and 3000 characters",
+				}
+				return c.JSON(http.StatusBadRequest, errorResponse)
+			}
+			if medicalRecord.Prescription == "" {
+				medicalRecord.Prescription = helper.SummarizePrescription(prescriptionItems)
 			}
-			return c.JSON(http.StatusBadRequest, errorResponse)
-		}
 
-		if len(medicalRecord.Prescription) < 1 || len(medicalRecord.Prescription) > 3000 {
-			errorResponse := helper.ErrorResponse{
-				Code:    http.StatusBadRequest,
-				Message: "Prescription must be between 1 and 3000 characters",
+			if len(medicalRecord.CareSuggestion) < 1 || len(medicalRecord.CareSuggestion) > 3000 {
+				errorResponse := helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Care suggestion must be between 1 and 3000 characters",
+				}
+				return c.JSON(http.StatusBadRequest, errorResponse)
 			}
-			return c.JSON(http.StatusBadRequest, errorResponse)
-		}
 
-		if len(medicalRecord.CareSuggestion) < 1 || len(medicalRecord.CareSuggestion) > 3000 {
-			errorResponse := helper.ErrorResponse{
-				Code:    http.StatusBadRequest,
-				Message: "Care suggestion must be between 1 and 3000 characters",
+			// Kode ICD-10 bersifat opsional, tetapi jika diisi harus terdaftar di tabel icd10_codes
+			primary, secondary, err := helper.ValidateRecordDiagnoses(db, medicalRecord.PrimaryICD10, medicalRecord.SecondaryICD10)
+			if err != nil {
+				var invalidDiagnosis *helper.InvalidDiagnosisError
+				if errors.As(err, &invalidDiagnosis) {
+					return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+						Code:    http.StatusBadRequest,
+						Message: err.Error(),
+					})
+				}
+				return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+					Code:    http.StatusInternalServerError,
+					Message: "Failed to validate ICD-10 codes",
+				})
 			}
-			return c.JSON(http.StatusBadRequest, errorResponse)
-		}
+			medicalRecord.PrimaryICD10 = primary
+			medicalRecord.SecondaryICD10 = secondary
 
-		if err := helper.SendMedicalRecordNotification(medicalRecord.Email, medicalRecord.PatientName, medicalRecord.Diagnosis, medicalRecord.Prescription, medicalRecord.CareSuggestion); err != nil {
-			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
-				Code:    http.StatusInternalServerError,
-				Message: "Failed to send medical record notification",
-			})
+			// Store the medicalRecord object in the context
+			c.Set("medicalRecord", medicalRecord)
+			return next(c)
 		}
-
-		// Store the medicalRecord object in the context
-		c.Set("medicalRecord", medicalRecord)
-		return next(c)
 	}
 }
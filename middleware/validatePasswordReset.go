@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"medis/helper"
+	"net/http"
+	"strings"
+)
+
+func ValidateForgotPassword(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var request helper.ForgotPasswordRequest
+		if err := c.Bind(&request); err != nil {
+			errorResponse := helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body",
+			}
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		}
+
+		request.Email = strings.TrimSpace(request.Email)
+		if !helper.ValidateEmailFormat(request.Email) {
+			errorResponse := helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid email format",
+			}
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		}
+
+		c.Set("forgotPassword", request)
+		return next(c)
+	}
+}
+
+func ValidateResetPassword(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var request helper.ResetPasswordRequest
+		if err := c.Bind(&request); err != nil {
+			errorResponse := helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body",
+			}
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		}
+
+		if request.Token == "" {
+			errorResponse := helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Reset token is required",
+			}
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		}
+
+		if len(request.NewPassword) < 8 || len(request.NewPassword) > 100 || !helper.IsValidPassword(request.NewPassword) {
+			errorResponse := helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Password must be at least 8 characters max 100 characters and contain a combination of letters and numbers",
+			}
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		}
+
+		c.Set("resetPassword", request)
+		return next(c)
+	}
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"medis/helper"
+	"net/http"
+	"strings"
+)
+
+// ValidatePatient memvalidasi data pasien baru. Nama, tanggal lahir dan nomor telepon wajib diisi.
+func ValidatePatient(next echo.HandlerFunc) echo.HandlerFunc {
+	return validatePatientRequest(false, next)
+}
+
+// ValidatePatientUpdate memvalidasi perubahan data pasien. Field yang kosong tidak diubah.
+func ValidatePatientUpdate(next echo.HandlerFunc) echo.HandlerFunc {
+	return validatePatientRequest(true, next)
+}
+
+func validatePatientRequest(partial bool, next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var request helper.PatientRequest
+		if err := c.Bind(&request); err != nil {
+			errorResponse := helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body",
+			}
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		}
+
+		request.NIK = strings.TrimSpace(request.NIK)
+		request.BPJSNumber = strings.TrimSpace(request.BPJSNumber)
+		request.Name = strings.TrimSpace(request.Name)
+		request.Gender = strings.ToLower(strings.TrimSpace(request.Gender))
+		request.Email = strings.TrimSpace(request.Email)
+		request.PhoneNumber = strings.TrimSpace(request.PhoneNumber)
+		request.Language = strings.ToLower(strings.TrimSpace(request.Language))
+		request.PreferredChannel = strings.ToLower(strings.TrimSpace(request.PreferredChannel))
+
+		if !partial || request.Name != "" {
+			if len(request.Name) < 1 || len(request.Name) > 100 || !helper.ValidateLettersAndSpaces(request.Name) {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Patient name must be between 1 and 100 characters and contain only letters and spaces",
+				})
+			}
+		}
+
+		if !partial || request.BirthDate != "" {
+			if !helper.ValidateDateFormat(request.BirthDate) {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Birth date must be in the format yyyy-mm-dd",
+				})
+			}
+		}
+
+		if request.Email != "" && !helper.ValidateEmailFormat(request.Email) {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid email format",
+			})
+		}
+
+		if !partial || request.PhoneNumber != "" {
+			if !helper.ValidatePhoneNumber(request.PhoneNumber) {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Phone number must contain only digits and be between 10 and 13 characters long",
+				})
+			}
+		}
+
+		if request.Gender != "" && request.Gender != helper.GenderMale && request.Gender != helper.GenderFemale {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Gender must be either male or female",
+			})
+		}
+
+		if request.BPJSNumber != "" && !helper.ValidateBPJSNumber(request.BPJSNumber) {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "BPJS number must be exactly 13 digits",
+			})
+		}
+
+		// Pada perubahan data, NIK dicocokkan dengan tanggal lahir setelah digabung dengan data lama di controller
+		if !partial && request.NIK != "" {
+			if err := helper.ValidateNIK(request.NIK, request.BirthDate, request.Gender); err != nil {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: err.Error(),
+				})
+			}
+			if request.Gender == "" {
+				request.Gender = helper.GenderFromNIK(request.NIK)
+			}
+		}
+
+		if request.Language != "" && !helper.IsSupportedLocale(request.Language) {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Language must be either id or en",
+			})
+		}
+
+		if request.PreferredChannel != "" && !helper.IsNotificationChannel(request.PreferredChannel) {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Preferred channel must be email, sms or whatsapp",
+			})
+		}
+
+		// Pada perubahan data, nomor telepon untuk SMS/WhatsApp diperiksa setelah digabung dengan data lama di controller
+		if !partial && helper.IsMessagingChannel(request.PreferredChannel) {
+			if _, err := helper.NormalizePhoneNumber(request.PhoneNumber); err != nil {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Phone number cannot be used for SMS or WhatsApp",
+				})
+			}
+		}
+
+		c.Set("patient", request)
+		return next(c)
+	}
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+	"strings"
+)
+
+func ValidateAllergy(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var request helper.AllergyRequest
+		if err := c.Bind(&request); err != nil {
+			errorResponse := helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body",
+			}
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		}
+
+		request.Substance = strings.TrimSpace(request.Substance)
+		request.KFACode = strings.TrimSpace(request.KFACode)
+		request.Reaction = strings.TrimSpace(request.Reaction)
+		request.Severity = strings.ToLower(strings.TrimSpace(request.Severity))
+
+		if request.Substance == "" && request.KFACode == "" {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Substance or KFA code is required",
+			})
+		}
+
+		if len(request.Substance) > 100 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Substance must be at most 100 characters",
+			})
+		}
+
+		if request.KFACode != "" && !helper.ValidateKFACode(request.KFACode) {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "KFA code must be 8 digits",
+			})
+		}
+
+		if len(request.Reaction) > 255 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Reaction must be at most 255 characters",
+			})
+		}
+
+		if !helper.AllergySeverities[request.Severity] {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Severity must be one of mild, moderate, severe or life_threatening",
+			})
+		}
+
+		c.Set("allergy", request)
+		return next(c)
+	}
+}
+
+func ValidateProblem(db *gorm.DB) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var request helper.ProblemRequest
+			if err := c.Bind(&request); err != nil {
+				errorResponse := helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Invalid request body",
+				}
+				return c.JSON(http.StatusBadRequest, errorResponse)
+			}
+
+			request.Description = strings.TrimSpace(request.Description)
+			request.Status = strings.ToLower(strings.TrimSpace(request.Status))
+			if request.Status == "" {
+				request.Status = models.ProblemStatusActive
+			}
+
+			if len(request.Description) < 1 || len(request.Description) > 500 {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Description must be between 1 and 500 characters",
+				})
+			}
+
+			if request.Status != models.ProblemStatusActive && request.Status != models.ProblemStatusInactive && request.Status != models.ProblemStatusResolved {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Status must be one of active, inactive or resolved",
+				})
+			}
+
+			if request.OnsetDate != "" && !helper.ValidateDateFormat(request.OnsetDate) {
+				return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Onset date must be in the format yyyy-mm-dd",
+				})
+			}
+
+			if request.ResolvedDate != "" {
+				if request.Status != models.ProblemStatusResolved || !helper.ValidateDateFormat(request.ResolvedDate) {
+					return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+						Code:    http.StatusBadRequest,
+						Message: "Resolved date must be in the format yyyy-mm-dd and is only allowed for resolved problems",
+					})
+				}
+				if request.OnsetDate != "" && request.ResolvedDate < request.OnsetDate {
+					return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+						Code:    http.StatusBadRequest,
+						Message: "Resolved date cannot be before onset date",
+					})
+				}
+			}
+
+			// Kode ICD-10 opsional, tetapi jika diisi harus terdaftar
+			if request.ICD10Code != "" {
+				code, _, err := helper.ValidateRecordDiagnoses(db, request.ICD10Code, nil)
+				if err != nil {
+					var invalidDiagnosis *helper.InvalidDiagnosisError
+					if errors.As(err, &invalidDiagnosis) {
+						return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+							Code:    http.StatusBadRequest,
+							Message: err.Error(),
+						})
+					}
+					return c.JSON(http.StatusInternalServerError, helper.ErrorResponse{
+						Code:    http.StatusInternalServerError,
+						Message: "Failed to validate ICD-10 code",
+					})
+				}
+				request.ICD10Code = code
+			}
+
+			c.Set("problem", request)
+			return next(c)
+		}
+	}
+}
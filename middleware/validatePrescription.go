@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"medis/helper"
+	"net/http"
+)
+
+func ValidatePrescriptionCheck(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var request helper.PrescriptionCheckRequest
+		if err := c.Bind(&request); err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body",
+			})
+		}
+
+		if len(request.PrescriptionItems) == 0 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "At least one prescription item is required",
+			})
+		}
+
+		items, err := helper.ValidatePrescriptionItems(request.PrescriptionItems)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: err.Error(),
+			})
+		}
+		request.PrescriptionItems = items
+
+		c.Set("prescriptionCheck", request)
+		return next(c)
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"medis/auth"
+	"medis/helper"
+	"medis/models"
+	"net/http"
+)
+
+func ValidateTwoFactorSignIn(db *gorm.DB, keys *auth.KeySet) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var request helper.TwoFactorSignInRequest
+			if err := c.Bind(&request); err != nil {
+				errorResponse := helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Invalid request body",
+				}
+				return c.JSON(http.StatusBadRequest, errorResponse)
+			}
+
+			if request.Code == "" && request.RecoveryCode == "" {
+				errorResponse := helper.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: "Authentication code or recovery code is required",
+				}
+				return c.JSON(http.StatusBadRequest, errorResponse)
+			}
+
+			claims, err := auth.VerifyChallengeToken(request.ChallengeToken, keys)
+			if err != nil {
+				errorResponse := helper.ErrorResponse{
+					Code:    http.StatusUnauthorized,
+					Message: "Invalid or expired challenge token",
+				}
+				return c.JSON(http.StatusUnauthorized, errorResponse)
+			}
+
+			if lockedUntil := helper.LoginLockedUntil(db, claims.Username, c.RealIP()); lockedUntil != nil {
+				return tooManySignInAttempts(c, *lockedUntil)
+			}
+
+			var existingDoctor models.Doctor
+			if err := db.Where("username = ?", claims.Username).First(&existingDoctor).Error; err != nil || !existingDoctor.TOTPEnabled {
+				errorResponse := helper.ErrorResponse{
+					Code:    http.StatusUnauthorized,
+					Message: "Invalid or expired challenge token",
+				}
+				return c.JSON(http.StatusUnauthorized, errorResponse)
+			}
+
+			if err := helper.VerifySecondFactor(db, &existingDoctor, request.Code, request.RecoveryCode); err != nil {
+				if errors.Is(err, helper.ErrInvalidSecondFactor) {
+					// Kode 2FA yang salah dihitung sebagai kegagalan login supaya kode 6 digit tidak bisa ditebak beruntun
+					helper.RecordLoginFailure(db, existingDoctor.Username, c.RealIP())
+					errorResponse := helper.ErrorResponse{
+						Code:    http.StatusUnauthorized,
+						Message: err.Error(),
+					}
+					return c.JSON(http.StatusUnauthorized, errorResponse)
+				}
+				errorResponse := helper.ErrorResponse{
+					Code:    http.StatusInternalServerError,
+					Message: "Failed to verify authentication code",
+				}
+				return c.JSON(http.StatusInternalServerError, errorResponse)
+			}
+
+			helper.ResetLoginFailures(db, existingDoctor.Username)
+			c.Set("doctor", existingDoctor)
+			c.Set("mfaVerified", true)
+			c.Set("organizationID", request.OrganizationID)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"medis/helper"
+	"net/http"
+	"time"
+)
+
+func ValidateVitalSign(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var request helper.VitalSignRequest
+		if err := c.Bind(&request); err != nil {
+			errorResponse := helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request body",
+			}
+			return c.JSON(http.StatusBadRequest, errorResponse)
+		}
+
+		vitalSign, err := helper.BuildVitalSign(request, time.Now())
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: err.Error(),
+			})
+		}
+
+		c.Set("vitalSign", vitalSign)
+		return next(c)
+	}
+}
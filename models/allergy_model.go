@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Tingkat keparahan reaksi alergi
+const (
+	AllergySeverityMild            = "mild"
+	AllergySeverityModerate        = "moderate"
+	AllergySeveritySevere          = "severe"
+	AllergySeverityLifeThreatening = "life_threatening"
+)
+
+// PatientAllergy mencatat alergi obat atau zat pada pasien. Minimal salah satu dari Substance atau KFACode terisi.
+type PatientAllergy struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	PatientID      uint      `gorm:"index" json:"patient_id"`      // Foreign key to Patient
+	OrganizationID uint      `gorm:"index" json:"organization_id"` // Foreign key to Organization
+	Substance      string    `json:"substance"`
+	KFACode        string    `gorm:"size:20" json:"kfa_code"`
+	Reaction       string    `json:"reaction"`
+	Severity       string    `json:"severity"`
+	RecordedByID   uint      `json:"recorded_by_id"` // Foreign key to Doctor
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Status janji temu. Hanya janji temu yang belum dibatalkan yang menempati slot dokter.
+const (
+	AppointmentStatusScheduled = "scheduled"
+	AppointmentStatusCompleted = "completed"
+	AppointmentStatusCancelled = "cancelled"
+)
+
+/*
+DoctorAvailability adalah jadwal praktik mingguan dokter di sebuah klinik. StartTime dan EndTime memakai format HH:MM
+dalam zona waktu klinik, dan jadwal dibagi menjadi slot sepanjang SlotMinutes.
+*/
+type DoctorAvailability struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	DoctorID       uint      `gorm:"index" json:"doctor_id"`       // Foreign key to Doctor
+	OrganizationID uint      `gorm:"index" json:"organization_id"` // Foreign key to Organization
+	Weekday        int       `json:"weekday"`
+	StartTime      string    `gorm:"size:5" json:"start_time"`
+	EndTime        string    `gorm:"size:5" json:"end_time"`
+	SlotMinutes    int       `json:"slot_minutes"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// DoctorLeave menandai tanggal (yyyy-mm-dd) saat dokter tidak praktik di klinik mana pun
+type DoctorLeave struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	DoctorID  uint      `gorm:"uniqueIndex:idx_doctor_leave_date" json:"doctor_id"` // Foreign key to Doctor
+	Date      string    `gorm:"size:10;uniqueIndex:idx_doctor_leave_date" json:"date"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+/*
+Appointment adalah janji temu pasien dengan dokter pada rentang [StartsAt, EndsAt). Tabel ini diberi exclusion
+constraint appointments_no_overlap (lihat config/migration.go) sehingga database sendiri menolak dua janji temu aktif
+yang bertumpuk untuk dokter yang sama.
+*/
+type Appointment struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"index" json:"organization_id"` // Foreign key to Organization
+	DoctorID       uint      `gorm:"index" json:"doctor_id"`       // Foreign key to Doctor
+	PatientID      uint      `gorm:"index" json:"patient_id"`      // Foreign key to Patient
+	StartsAt       time.Time `gorm:"index" json:"starts_at"`
+	EndsAt         time.Time `json:"ends_at"`
+	Status         string    `gorm:"size:20;default:scheduled" json:"status"`
+	Reason         string    `json:"reason"`
+	CancelReason   string    `json:"cancel_reason,omitempty"`
+	CreatedByID    uint      `json:"created_by_id"` // Foreign key to Doctor
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
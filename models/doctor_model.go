@@ -1,15 +1,44 @@
 package models
 
+import "time"
+
+// Status persetujuan akun dokter oleh admin
+const (
+	ApprovalStatusPending  = "pending"
+	ApprovalStatusApproved = "approved"
+	ApprovalStatusRejected = "rejected"
+)
+
 type Doctor struct {
-	ID                uint   `gorm:"primaryKey" json:"id"`
-	FirstName         string `json:"first_name"`
-	LastName          string `json:"last_name"`
-	Fullname          string `json:"fullname"`
-	ContactNumber     string `json:"contact_number"`
-	Gender            string `json:"gender"`
-	Email             string `json:"email"`
-	Username          string `json:"username"`
-	Password          string `json:"password"`
-	IsVerified        bool   `gorm:"default:false" json:"is_verified"`
-	VerificationToken string `json:"verification_token"`
+	ID                     uint       `gorm:"primaryKey" json:"id"`
+	FirstName              string     `json:"first_name"`
+	LastName               string     `json:"last_name"`
+	Fullname               string     `json:"fullname"`
+	ContactNumber          string     `json:"contact_number"`
+	Gender                 string     `json:"gender"`
+	Language               string     `gorm:"size:5;default:id" json:"language"` // Bahasa email: id atau en
+	Email                  string     `json:"email"`
+	PendingEmail           string     `json:"pending_email,omitempty"`
+	Username               string     `json:"username"`
+	Password               string     `json:"-"`
+	Role                   string     `gorm:"default:doctor" json:"role"`
+	STRNumber              string     `gorm:"index" json:"str_number"`
+	SIPNumber              string     `json:"sip_number"`
+	Specialty              string     `json:"specialty"`
+	LicenseExpiryDate      string     `json:"license_expiry_date"`
+	ApprovalStatus         string     `gorm:"default:pending" json:"approval_status"`
+	ApprovedAt             *time.Time `json:"approved_at"`
+	ApprovedByID           *uint      `json:"approved_by_id"`
+	RejectionReason        string     `json:"rejection_reason,omitempty"`
+	IsVerified             bool       `gorm:"default:false" json:"is_verified"`
+	VerificationToken      string     `json:"-"`
+	VerificationExpiresAt  *time.Time `json:"-"`
+	VerificationSentAt     *time.Time `json:"-"`
+	VerificationUsedAt     *time.Time `json:"-"`
+	TOTPSecret             string     `json:"-"`
+	TOTPEnabled            bool       `gorm:"default:false" json:"totp_enabled"`
+	TOTPLastUsedStep       int64      `json:"-"`
+	PasswordResetTokenHash string     `gorm:"index" json:"-"`
+	PasswordResetExpiresAt *time.Time `json:"-"`
+	PasswordResetSentAt    *time.Time `json:"-"`
 }
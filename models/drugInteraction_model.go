@@ -0,0 +1,23 @@
+package models
+
+// Tingkat keparahan interaksi obat, dari yang paling ringan
+const (
+	InteractionSeverityMinor           = "minor"
+	InteractionSeverityModerate        = "moderate"
+	InteractionSeverityMajor           = "major"
+	InteractionSeverityContraindicated = "contraindicated"
+)
+
+/*
+DrugInteraction adalah aturan interaksi antara dua obat yang diisi dari file data/drug_interactions.csv.
+DrugA dan DrugB berisi kode KFA 8 digit atau nama bahan aktif (huruf kecil), disimpan berurutan sehingga
+DrugA selalu lebih kecil dari DrugB.
+*/
+type DrugInteraction struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	DrugA       string `gorm:"size:100;uniqueIndex:idx_drug_interaction_pair" json:"drug_a"`
+	DrugB       string `gorm:"size:100;uniqueIndex:idx_drug_interaction_pair" json:"drug_b"`
+	Severity    string `gorm:"size:20" json:"severity"`
+	Description string `json:"description"`
+	Management  string `json:"management"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Jenis email pada outbox, menentukan template yang dipakai worker
+const (
+	EmailKindWelcome             = "welcome"
+	EmailKindPasswordReset       = "password_reset"
+	EmailKindEmailChange         = "email_change"
+	EmailKindLoginNotification   = "login_notification"
+	EmailKindAccountLockout      = "account_lockout"
+	EmailKindMedicalRecord       = "medical_record"
+	EmailKindAppointmentReminder = "appointment_reminder"
+)
+
+// EmailKinds adalah semua jenis email yang memiliki template
+var EmailKinds = []string{
+	EmailKindWelcome,
+	EmailKindPasswordReset,
+	EmailKindEmailChange,
+	EmailKindLoginNotification,
+	EmailKindAccountLockout,
+	EmailKindMedicalRecord,
+	EmailKindAppointmentReminder,
+}
+
+// Kanal pengiriman notifikasi. Selain email, outbox juga mengirim SMS dan WhatsApp ke pasien.
+const (
+	ChannelEmail    = "email"
+	ChannelSMS      = "sms"
+	ChannelWhatsApp = "whatsapp"
+)
+
+// Status email pada outbox. Email berstatus dead sudah melewati batas percobaan dan hanya dikirim ulang secara manual.
+const (
+	EmailStatusPending = "pending"
+	EmailStatusSent    = "sent"
+	EmailStatusDead    = "dead"
+)
+
+/*
+OutboxEmail adalah email yang menunggu dikirim. Baris ini ditulis di transaksi yang sama dengan data yang memicunya
+sehingga email hanya terkirim jika datanya benar-benar tersimpan. Payload berisi JSON yang dibutuhkan template, hanya
+berupa ID data dan tidak pernah memuat token, lalu dikosongkan setelah terkirim. Untuk kanal SMS dan WhatsApp,
+Recipient berisi nomor telepon dalam format E.164.
+*/
+type OutboxEmail struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Kind          string     `gorm:"size:30" json:"kind"`
+	Channel       string     `gorm:"size:20;default:email" json:"channel"`
+	Recipient     string     `json:"recipient"`
+	Payload       string     `gorm:"type:jsonb" json:"-"`
+	Status        string     `gorm:"size:20;default:pending;index:idx_outbox_email_due,priority:1" json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"index:idx_outbox_email_due,priority:2" json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (OutboxEmail) TableName() string {
+	return "email_outbox"
+}
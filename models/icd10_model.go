@@ -0,0 +1,18 @@
+package models
+
+// ICD10Code adalah tabel referensi kode diagnosis ICD-10 yang diisi dari file data/icd10.csv
+type ICD10Code struct {
+	Code        string `gorm:"primaryKey;size:10" json:"code"`
+	Description string `json:"description"`
+}
+
+func (ICD10Code) TableName() string {
+	return "icd10_codes"
+}
+
+// MedicalRecordDiagnosis menyimpan kode ICD-10 sekunder dari sebuah rekam medis
+type MedicalRecordDiagnosis struct {
+	ID              uint   `gorm:"primaryKey" json:"-"`
+	MedicalRecordID uint   `gorm:"index" json:"-"`
+	ICD10Code       string `gorm:"size:10;index" json:"icd10_code"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// LoginAttempt menghitung kegagalan login per kunci ("username:<nama>" atau "ip:<alamat>")
+type LoginAttempt struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	AttemptKey   string     `gorm:"uniqueIndex" json:"attempt_key"`
+	FailedCount  int        `json:"failed_count"`
+	LastFailedAt *time.Time `json:"last_failed_at"`
+	LockedUntil  *time.Time `json:"locked_until"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
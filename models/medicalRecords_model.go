@@ -3,15 +3,36 @@ package models
 import "time"
 
 type MedicalRecords struct {
-	ID             uint       `gorm:"primaryKey" json:"id"`
-	PatientName    string     `json:"patient_name"`
-	BirthDate      string     `json:"birth_date"`
-	Email          string     `json:"email"`
-	PhoneNumber    string     `json:"phone_number"`
-	Diagnosis      string     `json:"diagnosis"`
-	Prescription   string     `json:"prescription"`
-	CareSuggestion string     `json:"care_suggestion"`
-	DoctorID       uint       `json:"doctor_id"` // Foreign key to Doctor
-	CreatedAt      *time.Time `json:"created_at"`
-	UpdatedAt      time.Time
+	ID                uint                     `gorm:"primaryKey" json:"id"`
+	PatientID         uint                     `gorm:"index" json:"patient_id"` // Foreign key to Patient
+	PatientName       string                   `json:"patient_name"`
+	BirthDate         string                   `json:"birth_date"`
+	Email             string                   `json:"email"`
+	PhoneNumber       string                   `json:"phone_number"`
+	SOAP              SOAPNote                 `gorm:"embedded;embeddedPrefix:soap_" json:"soap"`
+	Diagnosis         string                   `json:"diagnosis"`
+	PrimaryICD10      string                   `gorm:"size:10;index" json:"primary_icd10"`
+	SecondaryICD10    []MedicalRecordDiagnosis `gorm:"foreignKey:MedicalRecordID;constraint:OnDelete:CASCADE" json:"secondary_icd10"`
+	Prescription      string                   `json:"prescription"`
+	PrescriptionItems []PrescriptionItem       `gorm:"foreignKey:MedicalRecordID;constraint:OnDelete:CASCADE" json:"prescription_items"`
+	CareSuggestion    string                   `json:"care_suggestion"`
+	AllergyOverride   string                   `json:"allergy_override_reason"`           // Alasan dokter tetap meresepkan obat yang cocok dengan alergi pasien
+	AppointmentID     *uint                    `gorm:"uniqueIndex" json:"appointment_id"` // Janji temu yang diselesaikan oleh kunjungan ini
+	DoctorID          uint                     `json:"doctor_id"`                         // Foreign key to Doctor
+	OrganizationID    uint                     `gorm:"index" json:"organization_id"`      // Foreign key to Organization
+	CreatedAt         *time.Time               `json:"created_at"`
+	UpdatedAt         time.Time
+}
+
+// SOAPNote adalah catatan kunjungan dalam format Subjective, Objective, Assessment, Plan. Semua bagian opsional
+// agar rekam medis lama yang hanya memakai diagnosis dan care suggestion tetap valid.
+type SOAPNote struct {
+	Subjective string `json:"subjective"`
+	Objective  string `json:"objective"`
+	Assessment string `json:"assessment"`
+	Plan       string `json:"plan"`
+}
+
+func (note SOAPNote) IsEmpty() bool {
+	return note.Subjective == "" && note.Objective == "" && note.Assessment == "" && note.Plan == ""
 }
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Peran anggota di dalam sebuah klinik. Owner boleh mengelola keanggotaan klinik.
+const (
+	MembershipRoleOwner  = "owner"
+	MembershipRoleMember = "member"
+)
+
+// Organization adalah klinik atau praktik tempat beberapa dokter berbagi data pasien
+type Organization struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `json:"name"`
+	Address     string    `json:"address"`
+	PhoneNumber string    `json:"phone_number"`
+	IsPersonal  bool      `gorm:"default:false" json:"is_personal"`
+	CreatedByID uint      `json:"created_by_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type Membership struct {
+	ID             uint         `gorm:"primaryKey" json:"id"`
+	OrganizationID uint         `gorm:"uniqueIndex:idx_membership_organization_doctor" json:"organization_id"`
+	DoctorID       uint         `gorm:"uniqueIndex:idx_membership_organization_doctor;index" json:"doctor_id"`
+	Role           string       `json:"role"`
+	Organization   Organization `json:"organization,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// OrganizationInvitation adalah undangan owner klinik untuk dokter lain. Dokter baru menjadi anggota setelah menerima undangan.
+type OrganizationInvitation struct {
+	ID             uint         `gorm:"primaryKey" json:"id"`
+	OrganizationID uint         `gorm:"uniqueIndex:idx_invitation_organization_doctor" json:"organization_id"`
+	DoctorID       uint         `gorm:"uniqueIndex:idx_invitation_organization_doctor;index" json:"doctor_id"`
+	Role           string       `json:"role"`
+	InvitedByID    uint         `json:"invited_by_id"`
+	Organization   Organization `json:"organization,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Patient menyimpan identitas pasien satu kali per klinik, rekam medis merujuk ke pasien lewat PatientID
+type Patient struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID   uint      `gorm:"index;uniqueIndex:idx_patient_organization_nik;uniqueIndex:idx_patient_organization_bpjs" json:"organization_id"` // Foreign key to Organization
+	NIK              string    `gorm:"uniqueIndex:idx_patient_organization_nik,where:nik <> ''" json:"nik"`                                             // Nomor Induk Kependudukan, unik per klinik
+	BPJSNumber       string    `gorm:"uniqueIndex:idx_patient_organization_bpjs,where:bpjs_number <> ''" json:"bpjs_number"`                            // Nomor kartu BPJS Kesehatan, unik per klinik
+	Name             string    `json:"name"`
+	Gender           string    `json:"gender"`
+	BirthDate        string    `json:"birth_date"`
+	Email            string    `json:"email"`
+	PhoneNumber      string    `json:"phone_number"`
+	Language         string    `gorm:"size:5;default:id" json:"language"`              // Bahasa email ke pasien: id atau en
+	PreferredChannel string    `gorm:"size:20;default:email" json:"preferred_channel"` // Kanal notifikasi: email, sms atau whatsapp
+	CreatedByID      uint      `json:"created_by_id"`                                  // Foreign key to Doctor
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// PrescriptionItem adalah satu baris resep yang merujuk ke produk obat di KFA (Kamus Farmasi dan Alat Kesehatan) SatuSehat
+type PrescriptionItem struct {
+	ID              uint    `gorm:"primaryKey" json:"id"`
+	MedicalRecordID uint    `gorm:"index" json:"-"`
+	KFACode         string  `gorm:"size:20;index" json:"kfa_code"`
+	ProductName     string  `json:"product_name"`
+	Dose            float64 `json:"dose"`
+	Unit            string  `json:"unit"`
+	Frequency       string  `json:"frequency"`
+	Route           string  `json:"route"`
+	DurationDays    int     `json:"duration_days"`
+	Quantity        float64 `json:"quantity"`
+}
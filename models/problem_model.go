@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Status masalah kesehatan pada problem list pasien
+const (
+	ProblemStatusActive   = "active"
+	ProblemStatusInactive = "inactive"
+	ProblemStatusResolved = "resolved"
+)
+
+// PatientProblem adalah satu penyakit kronis atau masalah kesehatan pada problem list pasien
+type PatientProblem struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	PatientID      uint      `gorm:"index" json:"patient_id"`      // Foreign key to Patient
+	OrganizationID uint      `gorm:"index" json:"organization_id"` // Foreign key to Organization
+	ICD10Code      string    `gorm:"size:10" json:"icd10_code"`
+	Description    string    `json:"description"`
+	Status         string    `json:"status"`
+	OnsetDate      string    `json:"onset_date"`
+	ResolvedDate   string    `json:"resolved_date"`
+	RecordedByID   uint      `json:"recorded_by_id"` // Foreign key to Doctor
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
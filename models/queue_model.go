@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Status antrian: waiting -> called -> done, atau skipped jika pasien tidak hadir saat dipanggil
+const (
+	QueueStatusWaiting = "waiting"
+	QueueStatusCalled  = "called"
+	QueueStatusSkipped = "skipped"
+	QueueStatusDone    = "done"
+)
+
+// QueueEntry adalah satu nomor antrian pasien untuk seorang dokter pada tanggal tertentu (yyyy-mm-dd, zona waktu klinik)
+type QueueEntry struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	OrganizationID uint       `gorm:"uniqueIndex:idx_queue_entry_number" json:"organization_id"` // Foreign key to Organization
+	DoctorID       uint       `gorm:"uniqueIndex:idx_queue_entry_number" json:"doctor_id"`       // Foreign key to Doctor
+	QueueDate      string     `gorm:"size:10;uniqueIndex:idx_queue_entry_number" json:"queue_date"`
+	Number         int        `gorm:"uniqueIndex:idx_queue_entry_number" json:"number"`
+	PatientID      uint       `gorm:"index" json:"patient_id"` // Foreign key to Patient
+	AppointmentID  *uint      `json:"appointment_id"`          // Foreign key to Appointment, jika pasien datang dengan janji temu
+	Status         string     `gorm:"size:20;default:waiting" json:"status"`
+	CheckedInByID  uint       `json:"checked_in_by_id"` // Foreign key to Doctor
+	CheckedInAt    time.Time  `json:"checked_in_at"`
+	CalledAt       *time.Time `json:"called_at"`
+	FinishedAt     *time.Time `json:"finished_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+/*
+QueueCounter menyimpan nomor antrian terakhir per klinik, dokter dan tanggal. Nomor baru diambil dengan satu
+statement upsert sehingga dua check-in bersamaan tidak pernah mendapat nomor yang sama.
+*/
+type QueueCounter struct {
+	OrganizationID uint   `gorm:"primaryKey;autoIncrement:false"`
+	DoctorID       uint   `gorm:"primaryKey;autoIncrement:false"`
+	QueueDate      string `gorm:"primaryKey;size:10"`
+	LastNumber     int
+}
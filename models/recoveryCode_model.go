@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RecoveryCode adalah kode cadangan sekali pakai untuk login ketika aplikasi authenticator tidak tersedia
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	DoctorID  uint       `gorm:"index" json:"doctor_id"`
+	CodeHash  string     `gorm:"index" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// RefreshToken menyimpan sesi login dokter. Token asli hanya dikirim ke client, database hanya menyimpan hash-nya.
+// Setiap rotasi membuat baris baru dengan FamilyID yang sama sehingga pemakaian ulang token lama bisa mencabut seluruh sesi.
+type RefreshToken struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	DoctorID uint   `gorm:"index" json:"doctor_id"`
+	FamilyID string `gorm:"index" json:"family_id"`
+	// Klinik yang aktif untuk sesi ini, dibawa ke token baru saat rotasi
+	OrganizationID uint       `json:"organization_id"`
+	TokenHash      string     `gorm:"uniqueIndex" json:"-"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+	ReplacedByID   *uint      `json:"replaced_by_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+/*
+VitalSign adalah satu kali pengukuran tanda vital pada sebuah kunjungan. Semua nilai disimpan dalam satuan baku:
+tekanan darah mmHg, nadi dan napas per menit, suhu °C, SpO2 %, tinggi cm, berat kg. Field kosong berarti tidak diukur.
+*/
+type VitalSign struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	MedicalRecordID uint      `gorm:"index" json:"medical_record_id"` // Foreign key to MedicalRecords
+	PatientID       uint      `gorm:"index" json:"patient_id"`        // Foreign key to Patient
+	OrganizationID  uint      `gorm:"index" json:"organization_id"`   // Foreign key to Organization
+	RecordedByID    uint      `json:"recorded_by_id"`                 // Foreign key to Doctor
+	MeasuredAt      time.Time `gorm:"index" json:"measured_at"`
+	SystolicBP      *int      `json:"systolic_bp"`
+	DiastolicBP     *int      `json:"diastolic_bp"`
+	PulseRate       *int      `json:"pulse_rate"`
+	RespiratoryRate *int      `json:"respiratory_rate"`
+	Temperature     *float64  `json:"temperature"`
+	SpO2            *int      `gorm:"column:spo2" json:"spo2"`
+	Height          *float64  `json:"height"`
+	Weight          *float64  `json:"weight"`
+	BMI             *float64  `gorm:"column:bmi" json:"bmi"`
+	CreatedAt       time.Time `json:"created_at"`
+}
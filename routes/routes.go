@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"medis/auth"
 	"medis/controllers"
+	"medis/helper"
 	"medis/middleware"
 	"net/http"
 )
@@ -18,49 +19,524 @@ func ServeHTML(c echo.Context) error {
 	return c.HTML(http.StatusOK, string(htmlData))
 }
 
-func SetupRoutes(e *echo.Echo, db *gorm.DB) {
+// SetupRoutes mendaftarkan semua endpoint. notifier dan messenger diteruskan ke controller yang mengirim notifikasi.
+func SetupRoutes(e *echo.Echo, db *gorm.DB, notifier helper.Notifier, messenger helper.MessagingProvider) {
 	e.Use(Logger())
-	secretKey := []byte(auth.GetSecretKeyFromEnv())
+	keys := auth.LoadKeySetFromEnv()
+	queueBroker := helper.NewQueueBroker()
 	e.GET("/", ServeHTML)
+	e.GET("/.well-known/jwks.json", controllers.GetJWKS(keys))
+
+	e.POST("/api/doctor/signup", middleware.ValidateDoctorRegistration(middleware.CheckDoctorUniqueness(db)(controllers.RegisterDoctor(db, keys))))
+	e.POST("/api/doctor/signin", middleware.ValidateDoctorSignIn(db)(controllers.SignInDoctor(db, keys)))
+	e.POST("/api/doctor/signin/2fa", middleware.ValidateTwoFactorSignIn(db, keys)(controllers.SignInDoctor(db, keys)))
+	e.POST("/api/doctor/token/refresh", controllers.RefreshDoctorToken(db, keys))
+	e.POST("/api/doctor/signout",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			controllers.SignOutDoctor(db),
+		),
+	)
+	e.POST("/api/doctor/password/forgot", middleware.ValidateForgotPassword(controllers.ForgotPassword(db)))
+	e.POST("/api/doctor/password/reset", middleware.ValidateResetPassword(controllers.ResetPassword(db)))
+
+	// Profile
+	e.GET("/api/doctor/me",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			controllers.GetProfile,
+		),
+	)
+	e.PUT("/api/doctor/me",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.ValidateProfileUpdate(
+				controllers.UpdateProfile(db),
+			),
+		),
+	)
+	e.PUT("/api/doctor/me/password",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.ValidateChangePassword(
+				controllers.ChangePassword(db),
+			),
+		),
+	)
+	e.PUT("/api/doctor/me/email",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.ValidateChangeEmail(
+				controllers.ChangeEmail(db),
+			),
+		),
+	)
+	e.PUT("/api/doctor/me/license",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.ValidateLicenseUpdate(
+				controllers.UpdateLicense(db),
+			),
+		),
+	)
+
+	// Two-factor authentication
+	e.POST("/api/doctor/2fa/setup",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			controllers.SetupTwoFactor(db),
+		),
+	)
+	e.POST("/api/doctor/2fa/enable",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			controllers.EnableTwoFactor(db),
+		),
+	)
+	e.POST("/api/doctor/2fa/disable",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			controllers.DisableTwoFactor(db),
+		),
+	)
+	e.POST("/api/doctor/2fa/recovery-codes",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			controllers.RegenerateRecoveryCodes(db),
+		),
+	)
 
-	e.POST("/api/doctor/signup", middleware.ValidateDoctorRegistration(middleware.CheckDoctorUniqueness(db)(controllers.RegisterDoctor(db, secretKey))))
-	e.POST("/api/doctor/signin", middleware.ValidateDoctorSignIn(db)(controllers.SignInDoctor(db, secretKey)))
 	e.GET("/verify", controllers.VerifyEmail(db))
+	e.POST("/api/doctor/verify/resend", middleware.ValidateResendVerification(controllers.ResendVerificationEmail(db)))
+	e.GET("/reset-password", controllers.ResetPasswordPage)
+	e.GET("/portal/records/:id", controllers.GetPortalMedicalRecord(db))
+
+	// Organization
+	e.POST("/api/organizations",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			controllers.CreateOrganization(db),
+		),
+	)
+	e.GET("/api/organizations",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			controllers.ListMyOrganizations(db),
+		),
+	)
+	e.POST("/api/organizations/:id/switch",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			controllers.SwitchOrganization(db, keys),
+		),
+	)
+	e.GET("/api/organizations/:id/members",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			controllers.ListOrganizationMembers(db),
+		),
+	)
+	e.POST("/api/organizations/:id/members",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			controllers.InviteOrganizationMember(db),
+		),
+	)
+	e.GET("/api/organizations/invitations",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			controllers.ListMyInvitations(db),
+		),
+	)
+	e.POST("/api/organizations/invitations/:id/accept",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			controllers.AcceptOrganizationInvitation(db),
+		),
+	)
+	e.POST("/api/organizations/invitations/:id/decline",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			controllers.DeclineOrganizationInvitation(db),
+		),
+	)
+	e.DELETE("/api/organizations/:id/members/:doctorId",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			controllers.RemoveOrganizationMember(db),
+		),
+	)
+
+	// Patient
+	e.POST("/api/patients",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionPatientWrite)(
+				middleware.ValidatePatient(
+					controllers.CreatePatient(db),
+				),
+			),
+		),
+	)
+	e.GET("/api/patients",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionPatientRead)(
+				controllers.GetPatients(db),
+			),
+		),
+	)
+	e.GET("/api/patients/:id",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionPatientRead)(
+				controllers.GetPatientByID(db),
+			),
+		),
+	)
+	e.PUT("/api/patients/:id",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionPatientWrite)(
+				middleware.ValidatePatientUpdate(
+					controllers.UpdatePatient(db),
+				),
+			),
+		),
+	)
+	e.DELETE("/api/patients/:id",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionPatientDelete)(
+				controllers.DeletePatient(db),
+			),
+		),
+	)
+
+	// Allergies and problem list
+	e.GET("/api/patients/:id/allergies",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionPatientRead)(
+				controllers.GetPatientAllergies(db),
+			),
+		),
+	)
+	e.POST("/api/patients/:id/allergies",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionPatientHistoryWrite)(
+				middleware.ValidateAllergy(
+					controllers.AddPatientAllergy(db),
+				),
+			),
+		),
+	)
+	e.DELETE("/api/patients/:id/allergies/:allergyId",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionPatientHistoryWrite)(
+				controllers.DeletePatientAllergy(db),
+			),
+		),
+	)
+	e.GET("/api/patients/:id/problems",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionRecordRead)(
+				controllers.GetPatientProblems(db),
+			),
+		),
+	)
+	e.POST("/api/patients/:id/problems",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionPatientHistoryWrite)(
+				middleware.ValidateProblem(db)(
+					controllers.AddPatientProblem(db),
+				),
+			),
+		),
+	)
+	e.PUT("/api/patients/:id/problems/:problemId",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionPatientHistoryWrite)(
+				middleware.ValidateProblem(db)(
+					controllers.UpdatePatientProblem(db),
+				),
+			),
+		),
+	)
+	e.DELETE("/api/patients/:id/problems/:problemId",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionPatientHistoryWrite)(
+				controllers.DeletePatientProblem(db),
+			),
+		),
+	)
 
 	// Satu Sehat
 	e.POST("/api/satusehat/auth", controllers.GetAuthToken)
 	e.GET("/api/satusehat/medicine", controllers.GetMedicineList)
 
+	// ICD-10
+	e.GET("/api/icd10",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			controllers.SearchICD10(db),
+		),
+	)
+
+	// Doctor schedule
+	e.GET("/api/doctor/availability",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionScheduleManage)(
+				controllers.GetMyAvailability(db),
+			),
+		),
+	)
+	e.POST("/api/doctor/availability",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionScheduleManage)(
+				middleware.ValidateAvailability(
+					controllers.AddMyAvailability(db),
+				),
+			),
+		),
+	)
+	e.DELETE("/api/doctor/availability/:availabilityId",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionScheduleManage)(
+				controllers.DeleteMyAvailability(db),
+			),
+		),
+	)
+	e.GET("/api/doctor/leaves",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionScheduleManage)(
+				controllers.GetMyLeaves(db),
+			),
+		),
+	)
+	e.POST("/api/doctor/leaves",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionScheduleManage)(
+				middleware.ValidateLeave(
+					controllers.AddMyLeave(db),
+				),
+			),
+		),
+	)
+	e.DELETE("/api/doctor/leaves/:leaveId",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionScheduleManage)(
+				controllers.DeleteMyLeave(db),
+			),
+		),
+	)
+	e.GET("/api/doctors/:id/slots",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionAppointmentManage)(
+				controllers.GetDoctorSlots(db),
+			),
+		),
+	)
+
+	// Appointments
+	e.POST("/api/appointments",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionAppointmentManage)(
+				middleware.ValidateAppointment(
+					controllers.BookAppointment(db),
+				),
+			),
+		),
+	)
+	e.GET("/api/appointments",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionAppointmentManage)(
+				controllers.GetAppointments(db),
+			),
+		),
+	)
+	e.GET("/api/appointments/:id",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionAppointmentManage)(
+				controllers.GetAppointmentByID(db),
+			),
+		),
+	)
+	e.PUT("/api/appointments/:id/reschedule",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionAppointmentManage)(
+				controllers.RescheduleAppointment(db),
+			),
+		),
+	)
+	e.PUT("/api/appointments/:id/cancel",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionAppointmentManage)(
+				controllers.CancelAppointment(db),
+			),
+		),
+	)
+	e.PUT("/api/appointments/:id/complete",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionRecordCreate)(
+				controllers.CompleteAppointment(db),
+			),
+		),
+	)
+
+	// Daily queue
+	e.POST("/api/queue/check-in",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionQueueManage)(
+				controllers.CheckInQueue(db, queueBroker),
+			),
+		),
+	)
+	e.GET("/api/queue",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionQueueManage)(
+				controllers.GetQueue(db),
+			),
+		),
+	)
+	e.POST("/api/queue/call-next",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionQueueManage)(
+				controllers.CallNextQueue(db, queueBroker),
+			),
+		),
+	)
+	e.PUT("/api/queue/:id/skip",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionQueueManage)(
+				controllers.SkipQueueEntry(db, queueBroker),
+			),
+		),
+	)
+	e.PUT("/api/queue/:id/done",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionQueueManage)(
+				controllers.FinishQueueEntry(db, queueBroker),
+			),
+		),
+	)
+	e.POST("/api/queue/display-token",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionQueueManage)(
+				controllers.CreateQueueDisplayToken(db, keys),
+			),
+		),
+	)
+	// Layar ruang tunggu memakai token khusus dari /api/queue/display-token, bukan access token dokter
+	e.GET("/api/queue/display/stream", controllers.StreamQueueDisplay(db, keys, queueBroker))
+
+	// Prescription checks
+	e.POST("/api/prescriptions/check",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionRecordRead)(
+				middleware.ValidatePrescriptionCheck(
+					controllers.CheckPrescription(db),
+				),
+			),
+		),
+	)
+
 	// Medical Record
 	e.POST("/api/doctor/medical-record",
-		middleware.VerifyDoctorTokenMiddleware(db, secretKey)(
-			middleware.ValidateMedicalRecord(
-				controllers.AddMedicalRecord(db),
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionRecordCreate)(
+				middleware.ValidateMedicalRecord(db)(
+					controllers.AddMedicalRecord(db),
+				),
 			),
 		),
 	)
 	e.GET("/api/doctor/medical-record",
-		middleware.VerifyDoctorTokenMiddleware(db, secretKey)(
-			controllers.GetMedicalRecordsByDoctor(db),
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionRecordRead)(
+				controllers.GetMedicalRecordsByDoctor(db),
+			),
 		),
 	)
 
 	e.GET("/api/doctor/medical-record/:id",
-		middleware.VerifyDoctorTokenMiddleware(db, secretKey)(
-			controllers.GetMedicalRecordByID(db),
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionRecordRead)(
+				controllers.GetMedicalRecordByID(db),
+			),
 		),
 	)
 
 	e.PUT("/api/doctor/medical-record/:id",
-		middleware.VerifyDoctorTokenMiddleware(db, secretKey)(
-			controllers.EditMedicalRecordByID(db),
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionRecordRead)(
+				controllers.EditMedicalRecordByID(db),
+			),
 		),
 	)
 
 	e.DELETE("/api/doctor/medical-record/:id",
-		middleware.VerifyDoctorTokenMiddleware(db, secretKey)(
-			controllers.DeleteMedicalRecordByID(db),
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionRecordDelete)(
+				controllers.DeleteMedicalRecordByID(db),
+			),
+		),
+	)
+
+	// Vital signs
+	e.POST("/api/doctor/medical-record/:id/vitals",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionVitalsWrite)(
+				middleware.ValidateVitalSign(
+					controllers.AddVitalSign(db),
+				),
+			),
+		),
+	)
+	e.GET("/api/doctor/medical-record/:id/vitals",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionRecordRead)(
+				controllers.GetVitalSigns(db),
+			),
+		),
+	)
+	e.DELETE("/api/doctor/medical-record/:id/vitals/:vitalId",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionVitalsWrite)(
+				controllers.DeleteVitalSign(db),
+			),
+		),
+	)
+	e.GET("/api/patients/:id/vitals",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionRecordRead)(
+				controllers.GetPatientVitalTrends(db),
+			),
+		),
+	)
+
+	// Admin
+	e.GET("/api/admin/users",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionUserManage)(
+				controllers.ListUsers(db),
+			),
+		),
+	)
+	e.POST("/api/admin/doctors/:id/approve",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionLicenseApprove)(
+				controllers.ApproveDoctor(db),
+			),
+		),
+	)
+	e.POST("/api/admin/doctors/:id/reject",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionLicenseApprove)(
+				controllers.RejectDoctor(db),
+			),
+		),
+	)
+	e.PUT("/api/admin/users/:id/role",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionUserManage)(
+				controllers.UpdateUserRole(db),
+			),
+		),
+	)
+	e.GET("/api/admin/outbox",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionOutboxManage)(
+				controllers.GetOutboxStatus(db),
+			),
+		),
+	)
+	e.POST("/api/admin/outbox/:id/retry",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionOutboxManage)(
+				controllers.RetryOutboxEmail(db, notifier, messenger),
+			),
+		),
+	)
+	e.GET("/api/admin/emails/:kind/preview",
+		middleware.VerifyDoctorTokenMiddleware(db, keys)(
+			middleware.RequirePermission(auth.PermissionOutboxManage)(
+				controllers.PreviewEmail,
+			),
 		),
 	)
-	
 }